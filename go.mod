@@ -3,16 +3,23 @@ module github.com/simplifi/looking-glass
 go 1.13
 
 require (
+	code.gitea.io/sdk/gitea v0.13.2
+	github.com/Azure/azure-storage-blob-go v0.10.0
 	github.com/aws/aws-sdk-go v1.26.7
 	github.com/frankban/quicktest v1.7.2 // indirect
 	github.com/google/go-github/v29 v29.0.3
 	github.com/jfrog/jfrog-client-go v0.7.0
+	github.com/jlaffaye/ftp v0.0.0-20200812143550-39e3779af0db
 	github.com/mitchellh/mapstructure v1.1.2
+	github.com/pkg/sftp v1.11.0
 	github.com/spf13/cobra v0.0.4
 	github.com/spf13/viper v1.4.0
-	github.com/stretchr/testify v1.3.0
-	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550 // indirect
+	github.com/stretchr/testify v1.6.1
+	github.com/ulikunitz/xz v0.5.15
+	github.com/xanzy/go-gitlab v0.50.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
-	golang.org/x/oauth2 v0.0.0-20180821212333-d2e6202438be
+	golang.org/x/oauth2 v0.0.0-20181106182150-f42d05182288
 	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -7,27 +7,37 @@ import (
 	"path"
 	"time"
 
-	"github.com/jfrog/jfrog-client-go/artifactory"
-	"github.com/jfrog/jfrog-client-go/artifactory/auth"
-	"github.com/jfrog/jfrog-client-go/artifactory/services"
-	aflog "github.com/jfrog/jfrog-client-go/utils/log"
 	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
 	"github.com/simplifi/looking-glass/pkg/looking-glass/downloader"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/uploader"
 )
 
-// Agent monitors a source for changes and pushes files to Artifactory
+// Agent monitors a source for changes and pushes files to one or more destinations
 type Agent struct {
-	artifactoryManager artifactory.ArtifactoryServicesManager
-	agentDownloader    downloader.Downloader
-	agentConfig        config.AgentConfig
-	localStoragePath   string
+	agentDownloader  downloader.Downloader
+	destinations     []destination
+	agentConfig      config.AgentConfig
+	localStoragePath string
+}
+
+// destination is one of the agent's uploaders, named for logging
+type destination struct {
+	name     string
+	uploader uploader.Uploader
 }
 
 // New Agent, pass in the ArtifactoryConfig, and AgentConfig
 func New(artifactoryConfig config.ArtifactoryConfig, agentConfig config.AgentConfig) (*Agent, error) {
-	artMgr, err := createArtifactoryManager(artifactoryConfig.URL, artifactoryConfig.Key, artifactoryConfig.UserName)
-	if err != nil {
-		return nil, err
+	var destinations []destination
+	for i, ulCfg := range uploaderConfigs(artifactoryConfig, agentConfig) {
+		ul, err := uploader.New(ulCfg)
+		if err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, destination{
+			name:     fmt.Sprintf("%s #%d", ulCfg.Type, i+1),
+			uploader: ul,
+		})
 	}
 	dl, err := downloader.New(agentConfig.Downloader)
 	if err != nil {
@@ -36,104 +46,151 @@ func New(artifactoryConfig config.ArtifactoryConfig, agentConfig config.AgentCon
 	localStoragePath := path.Join("/tmp", agentConfig.Name)
 
 	agent := Agent{
-		artifactoryManager: *artMgr,
-		agentDownloader:    dl,
-		agentConfig:        agentConfig,
-		localStoragePath:   localStoragePath,
+		agentDownloader:  dl,
+		destinations:     destinations,
+		agentConfig:      agentConfig,
+		localStoragePath: localStoragePath,
 	}
 
 	return &agent, nil
 }
 
-func createArtifactoryManager(url string, apiKey string, userName string) (*artifactory.ArtifactoryServicesManager, error) {
-	// You have to setup a logger for Artifactory client to work
-	aflog.SetLogger(aflog.NewLogger(aflog.ERROR, nil))
-
-	details := auth.NewArtifactoryDetails()
-	details.SetUrl(url)
-	details.SetApiKey(apiKey)
-	details.SetUser(userName)
-
-	serviceConfig, err := artifactory.NewConfigBuilder().
-		SetArtDetails(details).
-		SetDryRun(false).
-		Build()
-	if err != nil {
-		return nil, err
+// uploaderConfigs returns the agent's uploader configurations, agents without any upload to the
+// artifactory_repo on the top level Artifactory server
+func uploaderConfigs(artifactoryConfig config.ArtifactoryConfig, agentConfig config.AgentConfig) []config.UploaderConfig {
+	var ulCfgs []config.UploaderConfig
+	if agentConfig.Uploader.Type != "" {
+		ulCfgs = append(ulCfgs, agentConfig.Uploader)
 	}
-
-	mgr, err := artifactory.New(&details, serviceConfig)
-	if err != nil {
-		return nil, err
+	ulCfgs = append(ulCfgs, agentConfig.Uploaders...)
+	if len(ulCfgs) > 0 {
+		return ulCfgs
 	}
 
-	return mgr, nil
+	return []config.UploaderConfig{{
+		Type: "artifactory",
+		Config: map[string]interface{}{
+			"artifactory_url":      artifactoryConfig.URL,
+			"artifactory_username": artifactoryConfig.UserName,
+			"artifactory_key":      artifactoryConfig.Key,
+			"artifactory_repo":     agentConfig.ArtifactoryRepo,
+		},
+	}}
 }
 
 // Start the Agent
 func (agt *Agent) Start() {
 	for {
-		objs, err := agt.agentDownloader.ListObjects()
+		err := agt.mirror()
 		if err != nil {
 			log.Printf("ERROR: Failed to list objects - %s", err)
 		}
-		for _, obj := range objs {
-
-			// see if any objects are missing from Artifactory
-			if !agt.existsInArtifactory(obj) {
-				log.Printf("INFO: [mirror] %s", obj)
-
-				// download object to local storage
-				localFile := path.Join(agt.localStoragePath, obj)
-				dlErr := agt.agentDownloader.GetObject(obj, localFile)
-				if dlErr != nil {
-					log.Printf("ERROR: Failed to download object - %v", dlErr)
-				}
-
-				// upload to artifactory
-				rtErr := agt.uploadToArtifactory(localFile, obj)
-				if rtErr != nil {
-					log.Printf("ERROR: Failed to upload to Artifactory - %v", rtErr)
-				}
-
-				// clean up temp storage
-				rmErr := os.RemoveAll(agt.localStoragePath)
-				if rmErr != nil {
-					log.Printf("ERROR: Failed to clean up temp storage - %v", rmErr)
-				}
-			} else {
-				log.Printf("INFO: [skip] %s", obj)
-			}
-		}
 
 		log.Printf("INFO: Sleeping for %d seconds", agt.agentConfig.SleepDuration)
 		time.Sleep(time.Duration(agt.agentConfig.SleepDuration) * time.Second)
 	}
 }
 
-func (agt *Agent) uploadToArtifactory(sourceFile string, targetPath string) error {
-	params := services.NewUploadParams()
-	params.Pattern = sourceFile
-	params.Target = fmt.Sprintf("%s/%s", agt.agentConfig.ArtifactoryRepo, targetPath)
+// mirror makes a single pass over the source, downloading every object that is missing from any of the
+// destinations once and uploading it to each destination missing it
+func (agt *Agent) mirror() error {
+	objs, err := agt.agentDownloader.ListObjects()
+	if err != nil {
+		return err
+	}
+
+	uploaded := make([]int, len(agt.destinations))
+	failed := make([]int, len(agt.destinations))
+	for _, obj := range objs {
+		// object names come from the source, so never let one escape the local storage or the destination
+		if path.Clean("/"+obj) != "/"+obj {
+			log.Printf("ERROR: Skipping object with an unsafe name - %s", obj)
+			for i := range failed {
+				failed[i]++
+			}
+			continue
+		}
+
+		// see which destinations are missing the object, objects that change in place always need refreshing
+		mutable := agt.isMutable(obj)
+		var missing []int
+		for i, dest := range agt.destinations {
+			if mutable {
+				missing = append(missing, i)
+				continue
+			}
+
+			exists, err := dest.uploader.ObjectExists(obj)
+			if err != nil {
+				log.Printf("ERROR: [%s] Failed to check destination for object - %v", dest.name, err)
+				failed[i]++
+				continue
+			}
+			if !exists {
+				missing = append(missing, i)
+			}
+		}
+
+		if len(missing) == 0 {
+			log.Printf("INFO: [skip] %s", obj)
+			continue
+		}
 
-	_, _, totalFailed, err := agt.artifactoryManager.UploadFiles(params)
+		log.Printf("INFO: [mirror] %s", obj)
+		for j, ulErr := range agt.mirrorObject(obj, missing) {
+			if ulErr != nil {
+				failed[missing[j]]++
+			} else {
+				uploaded[missing[j]]++
+			}
+		}
+	}
 
-	if err != nil || totalFailed > 0 {
-		return fmt.Errorf("ERROR: failed to upload file %q, %v", sourceFile, err)
+	for i, dest := range agt.destinations {
+		log.Printf("INFO: [%s] %d objects uploaded, %d failed", dest.name, uploaded[i], failed[i])
 	}
 
 	return nil
 }
 
-func (agt *Agent) existsInArtifactory(filename string) bool {
-	exists := false
-	params := services.NewSearchParams()
-	params.Pattern = fmt.Sprintf("%s/%s", agt.agentConfig.ArtifactoryRepo, filename)
+// mirrorObject downloads the object to local storage once and uploads it to each of the destinations,
+// returning the error for each destination, if any
+func (agt *Agent) mirrorObject(obj string, destIdxs []int) []error {
+	errs := make([]error, len(destIdxs))
 
-	resp, _ := agt.artifactoryManager.SearchFiles(params)
+	// clean up temp storage
+	defer func() {
+		rmErr := os.RemoveAll(agt.localStoragePath)
+		if rmErr != nil {
+			log.Printf("ERROR: Failed to clean up temp storage - %v", rmErr)
+		}
+	}()
+
+	// download object to local storage
+	localFile := path.Join(agt.localStoragePath, path.Clean("/"+obj))
+	dlErr := agt.agentDownloader.GetObject(obj, localFile)
+	if dlErr != nil {
+		log.Printf("ERROR: Failed to download object - %v", dlErr)
+		for j := range errs {
+			errs[j] = dlErr
+		}
+		return errs
+	}
 
-	if len(resp) > 0 {
-		exists = true
+	// upload to each destination
+	for j, i := range destIdxs {
+		dest := agt.destinations[i]
+		errs[j] = dest.uploader.PutObject(localFile, obj)
+		if errs[j] != nil {
+			log.Printf("ERROR: [%s] Failed to upload object - %v", dest.name, errs[j])
+		}
 	}
-	return exists
+
+	return errs
+}
+
+// isMutable reports whether the downloader expects the object to change in place
+func (agt *Agent) isMutable(obj string) bool {
+	md, ok := agt.agentDownloader.(downloader.MutableDownloader)
+	return ok && md.IsMutable(obj)
 }
@@ -2,6 +2,10 @@ package agent
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
 	"testing"
 
 	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
@@ -99,3 +103,240 @@ func TestAgentMissingRequiredS3Configs(t *testing.T) {
 		assert.Equal(t, expectedError, err)
 	}
 }
+
+// memoryDownloader serves objects from a map, failing to download any object without content
+type memoryDownloader struct {
+	objects map[string]string
+	mutable map[string]bool
+}
+
+func (md *memoryDownloader) ListObjects() ([]string, error) {
+	var objects []string
+	for obj := range md.objects {
+		objects = append(objects, obj)
+	}
+	sort.Strings(objects)
+	return objects, nil
+}
+
+func (md *memoryDownloader) GetObject(sourceObj string, targetPath string) error {
+	if md.objects[sourceObj] == "" {
+		return fmt.Errorf("object '%s' not found", sourceObj)
+	}
+	os.MkdirAll(path.Dir(targetPath), os.ModePerm)
+	return ioutil.WriteFile(targetPath, []byte(md.objects[sourceObj]), 0644)
+}
+
+func (md *memoryDownloader) IsMutable(sourceObj string) bool {
+	return md.mutable[sourceObj]
+}
+
+// memoryUploader is an in-memory destination that records every upload
+type memoryUploader struct {
+	objects map[string]string
+	puts    []string
+}
+
+func (mu *memoryUploader) ObjectExists(targetObj string) (bool, error) {
+	_, ok := mu.objects[targetObj]
+	return ok, nil
+}
+
+func (mu *memoryUploader) PutObject(sourcePath string, targetObj string) error {
+	content, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	mu.objects[targetObj] = string(content)
+	mu.puts = append(mu.puts, targetObj)
+	return nil
+}
+
+func (mu *memoryUploader) ListObjects() ([]string, error) {
+	var objects []string
+	for obj := range mu.objects {
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func (mu *memoryUploader) DeleteObject(targetObj string) error {
+	delete(mu.objects, targetObj)
+	return nil
+}
+
+func TestAgentMirror(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "agent")
+	defer os.RemoveAll(tmpDir)
+
+	dl := &memoryDownloader{
+		objects: map[string]string{
+			"a/new.txt":      "new",
+			"a/existing.txt": "updated",
+			"a/broken.txt":   "",
+			"index.yaml":     "index",
+		},
+		mutable: map[string]bool{"index.yaml": true},
+	}
+	ul := &memoryUploader{
+		objects: map[string]string{
+			"a/existing.txt": "existing",
+			"index.yaml":     "old index",
+		},
+	}
+	agt := &Agent{
+		agentDownloader:  dl,
+		destinations:     []destination{{name: "memory #1", uploader: ul}},
+		localStoragePath: path.Join(tmpDir, "test"),
+	}
+
+	err := agt.mirror()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a/new.txt", "index.yaml"}, ul.puts)
+	assert.Equal(t, map[string]string{
+		"a/existing.txt": "existing",
+		"a/new.txt":      "new",
+		"index.yaml":     "index",
+	}, ul.objects)
+
+	// Nothing but the mutable object is mirrored on the next pass
+	ul.puts = nil
+	err = agt.mirror()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"index.yaml"}, ul.puts)
+}
+
+// countingDownloader wraps a downloader, counting the objects it downloads
+type countingDownloader struct {
+	*memoryDownloader
+	gets []string
+}
+
+func (cd *countingDownloader) GetObject(sourceObj string, targetPath string) error {
+	cd.gets = append(cd.gets, sourceObj)
+	return cd.memoryDownloader.GetObject(sourceObj, targetPath)
+}
+
+// failingUploader is a destination that is unreachable
+type failingUploader struct {
+	memoryUploader
+}
+
+func (fu *failingUploader) ObjectExists(targetObj string) (bool, error) {
+	return false, fmt.Errorf("destination unreachable")
+}
+
+func TestAgentMirrorMultipleDestinations(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "agent")
+	defer os.RemoveAll(tmpDir)
+
+	dl := &countingDownloader{memoryDownloader: &memoryDownloader{
+		objects: map[string]string{
+			"a/one.txt":   "one",
+			"a/two.txt":   "two",
+			"a/three.txt": "three",
+		},
+	}}
+	first := &memoryUploader{objects: map[string]string{"a/one.txt": "one"}}
+	second := &memoryUploader{objects: map[string]string{"a/one.txt": "one", "a/two.txt": "two"}}
+	unreachable := &failingUploader{memoryUploader{objects: map[string]string{}}}
+	agt := &Agent{
+		agentDownloader: dl,
+		destinations: []destination{
+			{name: "memory #1", uploader: first},
+			{name: "memory #2", uploader: second},
+			{name: "memory #3", uploader: unreachable},
+		},
+		localStoragePath: path.Join(tmpDir, "test"),
+	}
+
+	err := agt.mirror()
+	assert.NoError(t, err)
+
+	// Objects are only downloaded once, however many destinations are missing them
+	assert.Equal(t, []string{"a/three.txt", "a/two.txt"}, dl.gets)
+	assert.Equal(t, []string{"a/three.txt", "a/two.txt"}, first.puts)
+	assert.Equal(t, []string{"a/three.txt"}, second.puts)
+	assert.Empty(t, unreachable.puts)
+
+	// Nothing is downloaded once the reachable destinations are up to date
+	dl.gets = nil
+	err = agt.mirror()
+	assert.NoError(t, err)
+	assert.Empty(t, dl.gets)
+}
+
+func TestAgentMirrorUnsafeNames(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "agent")
+	defer os.RemoveAll(tmpDir)
+
+	dl := &countingDownloader{memoryDownloader: &memoryDownloader{
+		objects: map[string]string{
+			"../escape.txt":     "escape",
+			"a/../../other.txt": "other",
+			"/absolute.txt":     "absolute",
+			"a/safe.txt":        "safe",
+		},
+	}}
+	ul := &memoryUploader{objects: map[string]string{}}
+	agt := &Agent{
+		agentDownloader:  dl,
+		destinations:     []destination{{name: "memory #1", uploader: ul}},
+		localStoragePath: path.Join(tmpDir, "test"),
+	}
+
+	err := agt.mirror()
+	assert.NoError(t, err)
+
+	// Objects whose names are changed by cleaning them are never downloaded or uploaded
+	assert.Equal(t, []string{"a/safe.txt"}, dl.gets)
+	assert.Equal(t, []string{"a/safe.txt"}, ul.puts)
+	_, err = os.Stat(path.Join(tmpDir, "escape.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAgentUploaderConfig(t *testing.T) {
+	testArtifactoryCfg := config.ArtifactoryConfig{
+		URL:      "http://foo.bar",
+		UserName: "testing",
+		Key:      "123",
+	}
+
+	ulCfgs := uploaderConfigs(testArtifactoryCfg, config.AgentConfig{ArtifactoryRepo: "test"})
+	assert.Equal(t, []config.UploaderConfig{{
+		Type: "artifactory",
+		Config: map[string]interface{}{
+			"artifactory_url":      "http://foo.bar",
+			"artifactory_username": "testing",
+			"artifactory_key":      "123",
+			"artifactory_repo":     "test",
+		},
+	}}, ulCfgs)
+
+	agentUploaderCfg := config.UploaderConfig{Type: "artifactory", Config: map[string]interface{}{"artifactory_repo": "other"}}
+	ulCfgs = uploaderConfigs(testArtifactoryCfg, config.AgentConfig{ArtifactoryRepo: "test", Uploader: agentUploaderCfg})
+	assert.Equal(t, []config.UploaderConfig{agentUploaderCfg}, ulCfgs)
+
+	agentUploaderCfgs := []config.UploaderConfig{
+		{Type: "artifactory", Config: map[string]interface{}{"artifactory_repo": "other"}},
+		{Type: "filesystem", Config: map[string]interface{}{"filesystem_root": "/mnt/export"}},
+	}
+	ulCfgs = uploaderConfigs(testArtifactoryCfg, config.AgentConfig{ArtifactoryRepo: "test", Uploaders: agentUploaderCfgs})
+	assert.Equal(t, agentUploaderCfgs, ulCfgs)
+}
+
+func TestAgentBadUploadType(t *testing.T) {
+	testAgentConfig := config.AgentConfig{
+		Name: "test",
+		Uploader: config.UploaderConfig{
+			Type: "not-a-valid-type",
+		},
+		SleepDuration: 100,
+	}
+
+	expectedError := fmt.Errorf("unknown type not-a-valid-type")
+	_, err := New(config.ArtifactoryConfig{}, testAgentConfig)
+	if assert.Error(t, err) {
+		assert.Equal(t, expectedError, err)
+	}
+}
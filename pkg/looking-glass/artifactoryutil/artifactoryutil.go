@@ -0,0 +1,28 @@
+package artifactoryutil
+
+import (
+	"github.com/jfrog/jfrog-client-go/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/auth"
+	aflog "github.com/jfrog/jfrog-client-go/utils/log"
+)
+
+// NewManager creates a new Artifactory services manager, authenticating with the API key of the user
+func NewManager(url string, apiKey string, userName string) (*artifactory.ArtifactoryServicesManager, error) {
+	// You have to setup a logger for Artifactory client to work
+	aflog.SetLogger(aflog.NewLogger(aflog.ERROR, nil))
+
+	details := auth.NewArtifactoryDetails()
+	details.SetUrl(url)
+	details.SetApiKey(apiKey)
+	details.SetUser(userName)
+
+	serviceConfig, err := artifactory.NewConfigBuilder().
+		SetArtDetails(details).
+		SetDryRun(false).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return artifactory.New(&details, serviceConfig)
+}
@@ -0,0 +1,25 @@
+package awsutil
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// NewSession creates a new AWS session, the endpoint and path style addressing allow it to be used
+// with S3 compatible stores such as MinIO or Ceph RGW, and the default credential chain is used when
+// no key is given
+func NewSession(awsKey string, awsSecret string, awsRegion string, endpoint string, forcePathStyle bool) (*session.Session, error) {
+	cfg := &aws.Config{
+		Region:           aws.String(awsRegion),
+		S3ForcePathStyle: aws.Bool(forcePathStyle),
+	}
+	if awsKey != "" {
+		cfg.Credentials = credentials.NewStaticCredentials(awsKey, awsSecret, "")
+	}
+	if endpoint != "" {
+		cfg.Endpoint = aws.String(endpoint)
+	}
+
+	return session.NewSession(cfg)
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
-	"github.com/spf13/viper"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
 )
 
 /*
@@ -32,6 +36,37 @@ agents:
       config:
         github_repo: simplifi/looking-glass
         github_token: my-github-token
+  - name: my-uploader-agent
+    sleep_duration: 900
+    downloader:
+      type: github
+      config:
+        github_repo: simplifi/looking-glass
+    uploader:
+      type: artifactory
+      config:
+        artifactory_url: http://my.other.artifactory.server/artifactory/
+        artifactory_username: my-artifactory-user
+        artifactory_key: my-artifactory-key
+        artifactory_repo: my-repo
+  - name: my-fan-out-agent
+    sleep_duration: 900
+    downloader:
+      type: github
+      config:
+        github_repo: simplifi/looking-glass
+    uploaders:
+      - type: artifactory
+        config:
+          artifactory_url: http://my.us.artifactory.server/artifactory/
+          artifactory_repo: my-repo
+      - type: artifactory
+        config:
+          artifactory_url: http://my.eu.artifactory.server/artifactory/
+          artifactory_repo: my-repo
+      - type: filesystem
+        config:
+          filesystem_root: /mnt/export
 */
 
 // Config is used to store configuration for the Agents
@@ -53,11 +88,19 @@ type DownloaderConfig struct {
 	Config interface{} `mapstructure:"config"`
 }
 
+// UploaderConfig holds the configuration for the various uploaders
+type UploaderConfig struct {
+	Type   string      `mapstructure:"type"`
+	Config interface{} `mapstructure:"config"`
+}
+
 // AgentConfig holds Agent specific configuration
 type AgentConfig struct {
 	Name            string           `mapstructure:"name"`
 	ArtifactoryRepo string           `mapstructure:"artifactory_repo"`
 	Downloader      DownloaderConfig `mapstructure:"downloader"`
+	Uploader        UploaderConfig   `mapstructure:"uploader"`
+	Uploaders       []UploaderConfig `mapstructure:"uploaders"`
 	SleepDuration   int              `mapstructure:"sleep_duration"`
 }
 
@@ -83,3 +126,24 @@ func Read(configPath string) (*Config, error) {
 
 	return config, nil
 }
+
+// CheckRequired returns an error listing every required configuration value that is empty, it is used by
+// the downloaders and uploaders to validate their configuration
+func CheckRequired(requiredConfigs map[string]string) error {
+	var missingConfigs []string
+
+	// Check for configs that are not set
+	for cfgName, cfgValue := range requiredConfigs {
+		if cfgValue == "" {
+			missingConfigs = append(missingConfigs, cfgName)
+		}
+	}
+
+	// Error on all the missing configs
+	if len(missingConfigs) > 0 {
+		sort.Strings(missingConfigs)
+		return fmt.Errorf("configuration values cannot be empty: %s", strings.Join(missingConfigs, ", "))
+	}
+
+	return nil
+}
@@ -53,3 +53,46 @@ agents:
 	}
 	assert.Equal(t, expectedConfig, cfg.Agents[0].Downloader.Config)
 }
+
+func TestConfigReadUploaders(t *testing.T) {
+	content := []byte(`
+---
+agents:
+  - name: my-agent-name
+    sleep_duration: 900
+    downloader:
+      type: github
+      config:
+        github_repo: simplifi/looking-glass
+    uploaders:
+      - type: artifactory
+        config:
+          artifactory_url: http://my.artifactory.server/artifactory/
+          artifactory_repo: my-repo
+      - type: filesystem
+        config:
+          filesystem_root: /mnt/export
+`)
+	tmpfile, _ := ioutil.TempFile("", "config")
+
+	defer os.Remove(tmpfile.Name()) // clean up
+	defer tmpfile.Close()
+	tmpfile.Write(content)
+
+	cfg, err := Read(tmpfile.Name())
+	assert.NoError(t, err)
+
+	uploaders := cfg.Agents[0].Uploaders
+	if assert.Len(t, uploaders, 2) {
+		assert.Equal(t, "artifactory", uploaders[0].Type)
+		assert.Equal(t, "filesystem", uploaders[1].Type)
+		assert.Equal(t, map[interface{}]interface{}{"filesystem_root": "/mnt/export"}, uploaders[1].Config)
+	}
+}
+
+func TestCheckRequired(t *testing.T) {
+	assert.NoError(t, CheckRequired(map[string]string{"URL": "http://example.com"}))
+
+	err := CheckRequired(map[string]string{"URL": "", "Repo": "", "Key": "secret"})
+	assert.EqualError(t, err, "configuration values cannot be empty: Repo, URL")
+}
@@ -0,0 +1,435 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/ulikunitz/xz"
+)
+
+// aptReleaseFiles are the files describing a suite, Release is required while the signed variants
+// are mirrored when the repository publishes them
+var aptReleaseFiles = []string{"Release.gpg", "Release", "InRelease"}
+
+// aptPackagesFiles are the variants of a Packages index in order of preference for parsing
+var aptPackagesFiles = []string{"Packages.xz", "Packages.gz", "Packages"}
+
+type aptDownloaderConfig struct {
+	AptURL           string   `mapstructure:"apt_url"`
+	AptSuites        []string `mapstructure:"apt_suites"`
+	AptComponents    []string `mapstructure:"apt_components"`
+	AptArchitectures []string `mapstructure:"apt_architectures"`
+	AptUsername      string   `mapstructure:"apt_username"`
+	AptPassword      string   `mapstructure:"apt_password"`
+}
+
+type aptDownloader struct {
+	client        *http.Client
+	repoURL       *url.URL
+	suites        []string
+	components    []string
+	architectures []string
+	username      string
+	password      string
+	checksums     map[string]string
+	dists         map[string][]byte
+}
+
+// newApt returns an initialized aptDownloader struct
+func newApt(config config.DownloaderConfig) (Downloader, error) {
+	var cfg aptDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAptConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repoURL, err := url.Parse(cfg.AptURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repo URL is a directory, so make sure dists and pool resolve beneath it
+	if !strings.HasSuffix(repoURL.Path, "/") {
+		repoURL.Path += "/"
+	}
+
+	components := cfg.AptComponents
+	if len(components) == 0 {
+		components = []string{"main"}
+	}
+	architectures := cfg.AptArchitectures
+	if len(architectures) == 0 {
+		architectures = []string{"amd64"}
+	}
+
+	downloader := &aptDownloader{
+		client:        http.DefaultClient,
+		repoURL:       repoURL,
+		suites:        cfg.AptSuites,
+		components:    components,
+		architectures: architectures,
+		username:      cfg.AptUsername,
+		password:      cfg.AptPassword,
+		checksums:     map[string]string{},
+		dists:         map[string][]byte{},
+	}
+
+	return downloader, nil
+}
+
+// validateAptConfig validates the the configuration is not missing any required values
+func validateAptConfig(cfg aptDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"AptURL":    cfg.AptURL,
+		"AptSuites": strings.Join(cfg.AptSuites, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// parseDebControl parses the paragraphs of a Debian control file such as Release or Packages, the
+// lines of multi-line fields are joined with newlines
+func parseDebControl(r io.Reader) ([]map[string]string, error) {
+	var paragraphs []map[string]string
+	var paragraph map[string]string
+	var lastField string
+
+	scanner := bufio.NewScanner(r)
+	// Packages files can have very long Description and Depends fields
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			paragraph = nil
+		case line[0] == ' ' || line[0] == '\t':
+			if paragraph != nil && lastField != "" {
+				paragraph[lastField] += "\n" + strings.TrimSpace(line)
+			}
+		default:
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if paragraph == nil {
+				paragraph = map[string]string{}
+				paragraphs = append(paragraphs, paragraph)
+			}
+			lastField = parts[0]
+			paragraph[lastField] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return paragraphs, scanner.Err()
+}
+
+// request sends a request for the path relative to the repo URL
+func (ad *aptDownloader) request(method string, objectPath string) (*http.Response, error) {
+	req, err := http.NewRequest(method, ad.repoURL.ResolveReference(&url.URL{Path: objectPath}).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if ad.username != "" {
+		req.SetBasicAuth(ad.username, ad.password)
+	}
+
+	return ad.client.Do(req)
+}
+
+// getBody requests the path relative to the repo URL, failing on any non successful status
+func (ad *aptDownloader) getBody(objectPath string) (io.ReadCloser, error) {
+	resp, err := ad.request(http.MethodGet, objectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned %s", resp.Request.URL, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// getFile fetches the path relative to the repo URL, reporting false if it is not published, repositories
+// do not always serve every file their Release lists or the optional signed Release files
+func (ad *aptDownloader) getFile(objectPath string) ([]byte, bool, error) {
+	resp, err := ad.request(http.MethodGet, objectPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, false, fmt.Errorf("GET %s returned %s", resp.Request.URL, resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return content, true, nil
+}
+
+// aptRelease is the part of a suite's Release file needed to find its indexes
+type aptRelease struct {
+	// files maps each index listed in the Release to its SHA256
+	files       map[string]string
+	acquireHash bool
+}
+
+// parseRelease parses the Release file of the suite
+func parseRelease(suite string, content []byte) (*aptRelease, error) {
+	paragraphs, err := parseDebControl(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if len(paragraphs) == 0 {
+		return nil, fmt.Errorf("empty Release file for suite '%s'", suite)
+	}
+
+	release := &aptRelease{
+		files:       map[string]string{},
+		acquireHash: strings.EqualFold(paragraphs[0]["Acquire-By-Hash"], "yes"),
+	}
+	for _, line := range strings.Split(paragraphs[0]["SHA256"], "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 {
+			release.files[fields[2]] = fields[0]
+		}
+	}
+
+	return release, nil
+}
+
+// inReleaseBody returns the signed text of a clearsigned InRelease file, which should be the Release
+func inReleaseBody(content []byte) ([]byte, error) {
+	var body []string
+	inHeader, inBody := false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		switch {
+		case !inHeader && !inBody:
+			inHeader = line == "-----BEGIN PGP SIGNED MESSAGE-----"
+		case inHeader:
+			// The armor headers end at the first empty line
+			if line == "" {
+				inHeader, inBody = false, true
+			}
+		case line == "-----BEGIN PGP SIGNATURE-----":
+			return []byte(strings.Join(body, "\n")), nil
+		default:
+			// Lines starting with a dash are escaped in the signed text
+			body = append(body, strings.TrimPrefix(line, "- "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("InRelease is not a clearsigned message")
+}
+
+// parsePackages parses the first Packages variant of the component and architecture that was fetched
+func parsePackages(suite string, indexDir string, dists map[string][]byte) ([]map[string]string, error) {
+	for _, file := range aptPackagesFiles {
+		content, ok := dists[path.Join("dists", suite, indexDir, file)]
+		if !ok {
+			continue
+		}
+
+		var r io.Reader = bytes.NewReader(content)
+		var err error
+		switch path.Ext(file) {
+		case ".gz":
+			r, err = gzip.NewReader(r)
+		case ".xz":
+			r, err = xz.NewReader(r)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return parseDebControl(r)
+	}
+
+	return nil, fmt.Errorf("no Packages index for '%s' in suite '%s'", indexDir, suite)
+}
+
+// ListObjects lists the .deb files referenced by the Packages indexes of the configured suites,
+// components and architectures, followed by those indexes and each suite's Release files. The indexes
+// and Release files are fetched while listing and kept, and the listing fails while the InRelease or an
+// index does not match the Release, so the mirrored suite is always consistent
+func (ad *aptDownloader) ListObjects() ([]string, error) {
+	checksums := map[string]string{}
+	dists := map[string][]byte{}
+	var metadata []string
+
+	for _, suite := range ad.suites {
+		// The Release files are fetched together before the indexes, so they are as close to each other as
+		// the repository allows
+		var releaseFiles []string
+		for _, file := range aptReleaseFiles {
+			distPath := path.Join("dists", suite, file)
+			content, ok, err := ad.getFile(distPath)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				if file == "Release" {
+					return nil, fmt.Errorf("no Release file for suite '%s'", suite)
+				}
+				continue
+			}
+			dists[distPath] = content
+			releaseFiles = append(releaseFiles, distPath)
+		}
+
+		content := dists[path.Join("dists", suite, "Release")]
+		if inRelease, ok := dists[path.Join("dists", suite, "InRelease")]; ok {
+			body, err := inReleaseBody(inRelease)
+			if err != nil {
+				return nil, err
+			}
+
+			// Like a mismatched index, the suite has to be listed again once the repository has finished updating
+			if strings.TrimRight(string(body), "\n") != strings.TrimRight(string(content), "\n") {
+				return nil, fmt.Errorf("dists/%s/InRelease does not match the Release of suite '%s'", suite, suite)
+			}
+		}
+
+		release, err := parseRelease(suite, content)
+		if err != nil {
+			return nil, err
+		}
+
+		var indexes []string
+		for _, component := range ad.components {
+			for _, arch := range ad.architectures {
+				indexDir := path.Join(component, "binary-"+arch)
+
+				for indexPath, sum := range release.files {
+					if path.Dir(indexPath) != indexDir {
+						continue
+					}
+
+					distPath := path.Join("dists", suite, indexPath)
+					content, ok, err := ad.getFile(distPath)
+					if err != nil {
+						return nil, err
+					}
+					if !ok {
+						continue
+					}
+
+					// An index that does not match the Release was published after it, the suite has to be
+					// listed again once the repository has finished updating
+					digest := sha256.Sum256(content)
+					if hex.EncodeToString(digest[:]) != sum {
+						return nil, fmt.Errorf("%s does not match the Release of suite '%s'", distPath, suite)
+					}
+					dists[distPath] = content
+					indexes = append(indexes, distPath)
+
+					// Clients of repositories using Acquire-By-Hash request the indexes by their checksum
+					if release.acquireHash {
+						byHashPath := path.Join("dists", suite, indexDir, "by-hash", "SHA256", sum)
+						dists[byHashPath] = content
+						indexes = append(indexes, byHashPath)
+					}
+				}
+
+				packages, err := parsePackages(suite, indexDir, dists)
+				if err != nil {
+					return nil, err
+				}
+				for _, pkg := range packages {
+					if pkg["Filename"] != "" {
+						checksums[pkg["Filename"]] = pkg["SHA256"]
+					}
+				}
+			}
+		}
+		sort.Strings(indexes)
+		metadata = append(metadata, indexes...)
+		metadata = append(metadata, releaseFiles...)
+	}
+	ad.checksums = checksums
+	ad.dists = dists
+
+	var objects []string
+	for objectPath := range checksums {
+		objects = append(objects, objectPath)
+	}
+	sort.Strings(objects)
+
+	// The indexes and Release files go after the packages they reference
+	return append(objects, metadata...), nil
+}
+
+// IsMutable reports whether the object is one of the suites' Release files or indexes, which have to be
+// mirrored on every pass
+func (ad *aptDownloader) IsMutable(sourceObj string) bool {
+	return strings.HasPrefix(sourceObj, "dists/") && !strings.Contains(sourceObj, "/by-hash/")
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath, verifying packages against
+// the SHA256 from their index and writing the suites' files as they were listed
+func (ad *aptDownloader) GetObject(sourceObj string, targetPath string) error {
+	if strings.HasPrefix(sourceObj, "dists/") {
+		content, ok := ad.dists[sourceObj]
+		if !ok {
+			_, err := ad.ListObjects()
+			if err != nil {
+				return err
+			}
+
+			content, ok = ad.dists[sourceObj]
+			if !ok {
+				return fmt.Errorf("'%s' not found in the listed suites", sourceObj)
+			}
+		}
+
+		return writeObject(bytes.NewReader(content), targetPath)
+	}
+
+	body, err := ad.getBody(sourceObj)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	expected, err := hex.DecodeString(ad.checksums[sourceObj])
+	if err != nil || len(expected) == 0 {
+		return writeObject(body, targetPath)
+	}
+	return writeVerifiedObject(body, targetPath, sha256.New(), expected)
+}
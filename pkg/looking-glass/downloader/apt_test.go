@@ -0,0 +1,187 @@
+package downloader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDebControl(t *testing.T) {
+	paragraphs, err := parseDebControl(strings.NewReader("Package: a\nDescription: short\n long\n  longer\n\n\nPackage: b\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{"Package": "a", "Description": "short\nlong\nlonger"},
+		{"Package": "b"},
+	}, paragraphs)
+}
+
+// clearsign wraps the text in a clearsigned message like an InRelease file, without a real signature
+func clearsign(text string) string {
+	return "-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA256\n\n" + strings.TrimRight(text, "\n") +
+		"\n-----BEGIN PGP SIGNATURE-----\n\nsignature\n-----END PGP SIGNATURE-----\n"
+}
+
+func TestInReleaseBody(t *testing.T) {
+	body, err := inReleaseBody([]byte(clearsign("Origin: Vendor\n- -dashed\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, "Origin: Vendor\n-dashed", string(body))
+
+	_, err = inReleaseBody([]byte("Origin: Vendor\n"))
+	assert.EqualError(t, err, "InRelease is not a clearsigned message")
+}
+
+func TestAptListAndGetObjects(t *testing.T) {
+	var packagesGz bytes.Buffer
+	gz := gzip.NewWriter(&packagesGz)
+	fmt.Fprint(gz, `Package: agent
+Version: 1.0
+Filename: pool/main/a/agent/agent_1.0_amd64.deb
+SHA256: e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+
+Package: corrupt
+Version: 1.0
+Filename: pool/main/c/corrupt/corrupt_1.0_all.deb
+SHA256: e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+`)
+	gz.Close()
+
+	packagesSum := fmt.Sprintf("%x", sha256.Sum256(packagesGz.Bytes()))
+	release := fmt.Sprintf(`Origin: Vendor
+Acquire-By-Hash: yes
+SHA256:
+ 1111 10 main/binary-amd64/Packages
+ %s 20 main/binary-amd64/Packages.gz
+ 3333 30 main/binary-i386/Packages.gz
+`, packagesSum)
+	files := map[string]string{
+		"/debian/dists/stable/Release":                       release,
+		"/debian/dists/stable/InRelease":                     clearsign(release),
+		"/debian/dists/stable/main/binary-amd64/Packages.gz": packagesGz.String(),
+		"/debian/pool/main/a/agent/agent_1.0_amd64.deb":      "",
+		"/debian/pool/main/c/corrupt/corrupt_1.0_all.deb":    "corrupt",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "apt",
+		Config: map[interface{}]interface{}{
+			"apt_url":    server.URL + "/debian",
+			"apt_suites": []interface{}{"stable"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"pool/main/a/agent/agent_1.0_amd64.deb",
+		"pool/main/c/corrupt/corrupt_1.0_all.deb",
+		"dists/stable/main/binary-amd64/Packages.gz",
+		"dists/stable/main/binary-amd64/by-hash/SHA256/" + packagesSum,
+		"dists/stable/Release",
+		"dists/stable/InRelease",
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "apt-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("pool/main/a/agent/agent_1.0_amd64.deb", path.Join(tmpDir, "agent.deb"))
+	assert.NoError(t, err)
+	err = dl.GetObject("pool/main/c/corrupt/corrupt_1.0_all.deb", path.Join(tmpDir, "corrupt.deb"))
+	assert.EqualError(t, err, "checksum mismatch for corrupt.deb")
+
+	// The suite is served as it was listed, even once the repository has published a new one
+	files["/debian/dists/stable/InRelease"] = clearsign("Origin: Vendor\nSHA256:\n")
+	delete(files, "/debian/dists/stable/main/binary-amd64/Packages.gz")
+
+	err = dl.GetObject("dists/stable/InRelease", path.Join(tmpDir, "InRelease"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "InRelease"))
+	assert.Equal(t, clearsign(release), string(content))
+
+	err = dl.GetObject("dists/stable/main/binary-amd64/by-hash/SHA256/"+packagesSum, path.Join(tmpDir, "by-hash"))
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadFile(path.Join(tmpDir, "by-hash"))
+	assert.Equal(t, packagesGz.String(), string(content))
+
+	assert.True(t, dl.(MutableDownloader).IsMutable("dists/stable/main/binary-amd64/Packages.gz"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("dists/stable/main/binary-amd64/by-hash/SHA256/"+packagesSum))
+	assert.False(t, dl.(MutableDownloader).IsMutable("pool/main/a/agent/agent_1.0_amd64.deb"))
+}
+
+func TestAptIndexNotMatchingRelease(t *testing.T) {
+	files := map[string]string{
+		"/dists/stable/Release": `SHA256:
+ 1111 10 main/binary-amd64/Packages
+`,
+		"/dists/stable/main/binary-amd64/Packages": "Package: agent\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "apt",
+		Config: map[interface{}]interface{}{
+			"apt_url":    server.URL,
+			"apt_suites": []interface{}{"stable"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.EqualError(t, err, "dists/stable/main/binary-amd64/Packages does not match the Release of suite 'stable'")
+}
+
+func TestAptInReleaseNotMatchingRelease(t *testing.T) {
+	files := map[string]string{
+		"/dists/stable/Release":   "Origin: Vendor\nSHA256:\n",
+		"/dists/stable/InRelease": clearsign("Origin: Vendor\nSuite: newer\nSHA256:\n"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "apt",
+		Config: map[interface{}]interface{}{
+			"apt_url":    server.URL,
+			"apt_suites": []interface{}{"stable"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.EqualError(t, err, "dists/stable/InRelease does not match the Release of suite 'stable'")
+}
@@ -0,0 +1,94 @@
+package downloader
+
+import (
+	"path"
+	"strings"
+
+	"github.com/jfrog/jfrog-client-go/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/artifactoryutil"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+type artifactoryDownloaderConfig struct {
+	ArtifactoryURL      string `mapstructure:"artifactory_url"`
+	ArtifactoryUserName string `mapstructure:"artifactory_username"`
+	ArtifactoryKey      string `mapstructure:"artifactory_key"`
+	ArtifactoryRepo     string `mapstructure:"artifactory_repo"`
+	ArtifactoryPath     string `mapstructure:"artifactory_path"`
+}
+
+type artifactoryDownloader struct {
+	artifactoryManager *artifactory.ArtifactoryServicesManager
+	repo               string
+	repoPath           string
+}
+
+// newArtifactory returns an initialized artifactoryDownloader struct
+func newArtifactory(config config.DownloaderConfig) (Downloader, error) {
+	var cfg artifactoryDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateArtifactoryConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	artMgr, err := artifactoryutil.NewManager(cfg.ArtifactoryURL, cfg.ArtifactoryKey, cfg.ArtifactoryUserName)
+	if err != nil {
+		return nil, err
+	}
+
+	downloader := &artifactoryDownloader{
+		artifactoryManager: artMgr,
+		repo:               cfg.ArtifactoryRepo,
+		repoPath:           strings.Trim(cfg.ArtifactoryPath, "/"),
+	}
+
+	return downloader, nil
+}
+
+// validateArtifactoryConfig validates the the configuration is not missing any required values
+func validateArtifactoryConfig(cfg artifactoryDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"ArtifactoryURL":  cfg.ArtifactoryURL,
+		"ArtifactoryRepo": cfg.ArtifactoryRepo,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// ListObjects lists the files beneath the path in the Artifactory repo, relative to the repo
+func (ard *artifactoryDownloader) ListObjects() ([]string, error) {
+	var objects []string
+
+	params := services.NewSearchParams()
+	params.Pattern = path.Join(ard.repo, ard.repoPath, "*")
+	params.Recursive = true
+
+	resp, err := ard.artifactoryManager.SearchFiles(params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range resp {
+		objects = append(objects, path.Join(item.Path, item.Name))
+	}
+
+	return objects, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (ard *artifactoryDownloader) GetObject(sourceObj string, targetPath string) error {
+	rc, err := ard.artifactoryManager.ReadRemoteFile(path.Join(ard.repo, sourceObj))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return writeObject(rc, targetPath)
+}
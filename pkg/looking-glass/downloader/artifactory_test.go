@@ -0,0 +1,83 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestArtifactoryServer fakes the AQL search and file download endpoints for a repo named test
+func newTestArtifactoryServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The client sends the user and API key as basic auth
+		user, key, _ := r.BasicAuth()
+		assert.Equal(t, "mirror", user)
+		assert.Equal(t, "secret", key)
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/search/aql":
+			query, _ := ioutil.ReadAll(r.Body)
+			assert.Contains(t, string(query), `"repo":"test"`)
+			assert.Contains(t, string(query), `"path":{"$match":"vendor/*"}`)
+
+			fmt.Fprint(w, `{"results":[
+				{"repo":"test","path":"vendor","name":"README","type":"file"},
+				{"repo":"test","path":"vendor/v1.0","name":"tool.tar.gz","type":"file"}
+			],"range":{"start_pos":0,"end_pos":2,"total":2}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/test/vendor/v1.0/tool.tar.gz":
+			fmt.Fprint(w, "tarball")
+		default:
+			t.Logf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestArtifactoryListAndGetObjects(t *testing.T) {
+	server := newTestArtifactoryServer(t)
+	defer server.Close()
+
+	tmpDir, _ := ioutil.TempDir("", "artifactory")
+	defer os.RemoveAll(tmpDir)
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "artifactory",
+		Config: map[interface{}]interface{}{
+			"artifactory_url":      server.URL + "/",
+			"artifactory_username": "mirror",
+			"artifactory_key":      "secret",
+			"artifactory_repo":     "test",
+			"artifactory_path":     "/vendor/",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor/README", "vendor/v1.0/tool.tar.gz"}, objects)
+
+	target := path.Join(tmpDir, "vendor/v1.0/tool.tar.gz")
+	err = dl.GetObject("vendor/v1.0/tool.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "tarball", string(content))
+}
+
+func TestArtifactoryMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "artifactory",
+		Config: map[interface{}]interface{}{
+			"artifactory_username": "mirror",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: ArtifactoryRepo, ArtifactoryURL")
+}
@@ -0,0 +1,138 @@
+package downloader
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"golang.org/x/net/context"
+)
+
+type azureBlobConfig struct {
+	AzureAccountName string `mapstructure:"azure_account_name"`
+	AzureAccountKey  string `mapstructure:"azure_account_key"`
+	AzureSasToken    string `mapstructure:"azure_sas_token"`
+	AzureContainer   string `mapstructure:"azure_container"`
+	AzurePrefix      string `mapstructure:"azure_prefix"`
+	AzureEndpoint    string `mapstructure:"azure_endpoint"`
+}
+
+type azureBlob struct {
+	containerURL azblob.ContainerURL
+	azurePrefix  string
+}
+
+func newAzureBlob(config config.DownloaderConfig) (Downloader, error) {
+	var cfg azureBlobConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAzureBlobConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	containerURL, err := createAzureContainerURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	downloader := azureBlob{
+		containerURL: containerURL,
+		azurePrefix:  cfg.AzurePrefix,
+	}
+
+	return &downloader, nil
+}
+
+func validateAzureBlobConfig(cfg azureBlobConfig) error {
+	requiredConfigs := map[string]string{
+		"AzureAccountName": cfg.AzureAccountName,
+		"AzureContainer":   cfg.AzureContainer,
+	}
+
+	err := config.CheckRequired(requiredConfigs)
+	if err != nil {
+		return err
+	}
+
+	if cfg.AzureAccountKey != "" && cfg.AzureSasToken != "" {
+		return fmt.Errorf("only one of azure_account_key or azure_sas_token can be set")
+	}
+
+	return nil
+}
+
+// createAzureContainerURL builds the container URL using shared key, SAS token or anonymous access
+func createAzureContainerURL(cfg azureBlobConfig) (azblob.ContainerURL, error) {
+	// Azurite and sovereign clouds need the endpoint overridden, e.g. http://127.0.0.1:10000/devstoreaccount1
+	endpoint := fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AzureAccountName)
+	if cfg.AzureEndpoint != "" {
+		endpoint = strings.TrimSuffix(cfg.AzureEndpoint, "/")
+	}
+
+	serviceURL, err := url.Parse(endpoint)
+	if err != nil {
+		return azblob.ContainerURL{}, err
+	}
+
+	var credential azblob.Credential
+	if cfg.AzureAccountKey != "" {
+		credential, err = azblob.NewSharedKeyCredential(cfg.AzureAccountName, cfg.AzureAccountKey)
+		if err != nil {
+			return azblob.ContainerURL{}, err
+		}
+	} else {
+		// SAS tokens authenticate through the query string, so the request itself is anonymous
+		credential = azblob.NewAnonymousCredential()
+		serviceURL.RawQuery = strings.TrimPrefix(cfg.AzureSasToken, "?")
+	}
+
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	return azblob.NewServiceURL(*serviceURL, pipeline).NewContainerURL(cfg.AzureContainer), nil
+}
+
+// ListObjects lists the blobs available in the Azure container
+func (azb *azureBlob) ListObjects() ([]string, error) {
+	var objects []string
+
+	ctx := context.Background()
+
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := azb.containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{
+			Prefix: azb.azurePrefix,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range resp.Segment.BlobItems {
+			objects = append(objects, blob.Name)
+		}
+
+		marker = resp.NextMarker
+	}
+
+	return objects, nil
+}
+
+// GetObject downloads the blob specified in sourceObj to the targetPath
+func (azb *azureBlob) GetObject(sourceObj string, targetPath string) error {
+	ctx := context.Background()
+
+	blobURL := azb.containerURL.NewBlobURL(sourceObj)
+	resp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return err
+	}
+
+	body := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 3})
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
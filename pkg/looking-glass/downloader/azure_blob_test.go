@@ -0,0 +1,115 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAzureBlobServer fakes the List Blobs and Get Blob endpoints of an Azurite style endpoint for a
+// container named test, returning the listing in two pages
+func newTestAzureBlobServer(t *testing.T) *httptest.Server {
+	blobs := map[string]string{
+		"vendor/a.tar.gz":   "a",
+		"vendor/b c.tar.gz": "b",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The SAS token must be passed through on every request
+		assert.Equal(t, "read", r.URL.Query().Get("sig"))
+
+		const containerPath = "/devstoreaccount1/test"
+
+		if r.URL.Path == containerPath && r.URL.Query().Get("comp") == "list" {
+			assert.Equal(t, "container", r.URL.Query().Get("restype"))
+			assert.Equal(t, "vendor/", r.URL.Query().Get("prefix"))
+
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="test"><Blobs>`)
+			if r.URL.Query().Get("marker") == "" {
+				fmt.Fprint(w, `<Blob><Name>vendor/a.tar.gz</Name><Properties><BlobType>BlockBlob</BlobType></Properties></Blob>`)
+				fmt.Fprint(w, `</Blobs><NextMarker>page2</NextMarker></EnumerationResults>`)
+			} else {
+				fmt.Fprint(w, `<Blob><Name>vendor/b c.tar.gz</Name><Properties><BlobType>BlockBlob</BlobType></Properties></Blob>`)
+				fmt.Fprint(w, `</Blobs><NextMarker /></EnumerationResults>`)
+			}
+			return
+		}
+
+		if blob, ok := blobs[strings.TrimPrefix(r.URL.Path, containerPath+"/")]; ok && r.Method == http.MethodGet {
+			w.Header().Set("Content-Length", fmt.Sprint(len(blob)))
+			w.Header().Set("x-ms-blob-type", "BlockBlob")
+			fmt.Fprint(w, blob)
+			return
+		}
+
+		w.Header().Set("x-ms-error-code", "BlobNotFound")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+func TestAzureBlobListAndGetObjects(t *testing.T) {
+	server := newTestAzureBlobServer(t)
+	defer server.Close()
+
+	tmpDir, _ := ioutil.TempDir("", "azure-blob")
+	defer os.RemoveAll(tmpDir)
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "azure_blob",
+		Config: map[interface{}]interface{}{
+			"azure_account_name": "devstoreaccount1",
+			"azure_container":    "test",
+			"azure_prefix":       "vendor/",
+			"azure_sas_token":    "?sv=2019-02-02&sig=read",
+			"azure_endpoint":     server.URL + "/devstoreaccount1/",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor/a.tar.gz", "vendor/b c.tar.gz"}, objects)
+
+	target := path.Join(tmpDir, "vendor/b c.tar.gz")
+	err = dl.GetObject("vendor/b c.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(content))
+
+	err = dl.GetObject("vendor/missing.tar.gz", path.Join(tmpDir, "vendor/missing.tar.gz"))
+	assert.Error(t, err)
+}
+
+func TestAzureBlobKeyAndSasToken(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "azure_blob",
+		Config: map[interface{}]interface{}{
+			"azure_account_name": "devstoreaccount1",
+			"azure_container":    "test",
+			"azure_account_key":  "a2V5",
+			"azure_sas_token":    "sv=2019-02-02&sig=read",
+		},
+	})
+	assert.EqualError(t, err, "only one of azure_account_key or azure_sas_token can be set")
+}
+
+func TestAzureBlobMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "azure_blob",
+		Config: map[interface{}]interface{}{
+			"azure_prefix": "vendor/",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: AzureAccountName, AzureContainer")
+}
@@ -1,7 +1,12 @@
 package downloader
 
 import (
+	"bytes"
 	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
 
 	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
 )
@@ -12,6 +17,17 @@ type Downloader interface {
 	GetObject(string, string) error
 }
 
+// MutableDownloader is implemented by downloaders that list objects which change in place, such as
+// repository indexes, so they are mirrored on every pass rather than only when missing.
+//
+// An index must never be mirrored before the objects it references, so ListObjects lists mutable objects
+// after everything they reference, and GetObject serves them as they were at the last listing rather than
+// fetching them again, so they only reference objects that listing included
+type MutableDownloader interface {
+	Downloader
+	IsMutable(string) bool
+}
+
 // New Downloader, pass in the DownloaderConfig
 func New(config config.DownloaderConfig) (Downloader, error) {
 	switch config.Type {
@@ -19,7 +35,89 @@ func New(config config.DownloaderConfig) (Downloader, error) {
 		return newS3(config)
 	case "github":
 		return newGithub(config)
+	case "github_actions":
+		return newGithubActions(config)
+	case "http":
+		return newHTTP(config)
+	case "ftp":
+		return newFTP(config)
+	case "sftp":
+		return newSFTP(config)
+	case "filesystem":
+		return newFilesystem(config)
+	case "gcs":
+		return newGcs(config)
+	case "azure_blob":
+		return newAzureBlob(config)
+	case "gitlab":
+		return newGitlab(config)
+	case "gitea":
+		return newGitea(config)
+	case "artifactory":
+		return newArtifactory(config)
+	case "oci":
+		return newOCI(config)
+	case "helm":
+		return newHelm(config)
+	case "pypi":
+		return newPypi(config)
+	case "npm":
+		return newNpm(config)
+	case "maven":
+		return newMaven(config)
+	case "goproxy":
+		return newGoproxy(config)
+	case "hashicorp":
+		return newHashicorp(config)
+	case "apt":
+		return newApt(config)
+	case "yum":
+		return newYum(config)
 	default:
 		return nil, fmt.Errorf("unknown type %s", config.Type)
 	}
 }
+
+// createTargetFile creates the file at targetPath, creating any missing directories
+func createTargetFile(targetPath string) (*os.File, error) {
+	// Ensure the temporary download path exists
+	err := os.MkdirAll(path.Dir(targetPath), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Create(targetPath)
+}
+
+// writeObject writes the contents of r to the targetPath, creating any missing directories
+func writeObject(r io.Reader, targetPath string) error {
+	f, err := createTargetFile(targetPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeVerifiedObject writes the contents of r to the targetPath like writeObject, removing the file and
+// returning an error if its digest under h does not match expected
+func writeVerifiedObject(r io.Reader, targetPath string, h hash.Hash, expected []byte) error {
+	err := writeObject(io.TeeReader(r, h), targetPath)
+	if err != nil {
+		return err
+	}
+
+	// Remove the corrupt file so it can never be uploaded
+	if !bytes.Equal(h.Sum(nil), expected) {
+		os.Remove(targetPath)
+		return fmt.Errorf("checksum mismatch for %s", path.Base(targetPath))
+	}
+
+	return nil
+}
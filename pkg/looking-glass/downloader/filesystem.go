@@ -0,0 +1,104 @@
+package downloader
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+type filesystemDownloaderConfig struct {
+	FilesystemRoot string `mapstructure:"filesystem_root"`
+}
+
+type filesystemDownloader struct {
+	root string
+}
+
+// newFilesystem returns an initialized filesystemDownloader struct
+func newFilesystem(config config.DownloaderConfig) (Downloader, error) {
+	var cfg filesystemDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFilesystemConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	downloader := &filesystemDownloader{
+		root: filepath.Clean(cfg.FilesystemRoot),
+	}
+
+	return downloader, nil
+}
+
+// validateFilesystemConfig validates the the configuration is not missing any required values
+func validateFilesystemConfig(cfg filesystemDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"FilesystemRoot": cfg.FilesystemRoot,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// ListObjects lists the files beneath the root directory, relative to the root. The root may be a
+// symlink, but symlinks to directories beneath it are not followed
+func (fsd *filesystemDownloader) ListObjects() ([]string, error) {
+	var objects []string
+
+	// Walk does not descend into a symlink, so resolve the root in case it is one
+	root, err := filepath.EvalSymlinks(fsd.root)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Symlinks to files are mirrored as the file they point to, while a dangling symlink is skipped
+		// rather than failing the whole listing
+		if info.Mode()&os.ModeSymlink != 0 {
+			info, err = os.Stat(filePath)
+			if err != nil {
+				log.Printf("WARN: Skipping unreadable symlink - %s", err)
+				return nil
+			}
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, filepath.ToSlash(relPath))
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// GetObject copies the object specified in sourceObj to the targetPath
+func (fsd *filesystemDownloader) GetObject(sourceObj string, targetPath string) error {
+	f, err := os.Open(filepath.Join(fsd.root, filepath.FromSlash(sourceObj)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeObject(f, targetPath)
+}
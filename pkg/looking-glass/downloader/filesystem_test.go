@@ -0,0 +1,91 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesystemListAndGetObjects(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "filesystem-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "filesystem-dst")
+	defer os.RemoveAll(dstDir)
+
+	os.MkdirAll(path.Join(srcDir, "vendor/v1.0"), os.ModePerm)
+	os.MkdirAll(path.Join(srcDir, "empty"), os.ModePerm)
+	ioutil.WriteFile(path.Join(srcDir, "README"), []byte("readme"), 0644)
+	ioutil.WriteFile(path.Join(srcDir, "vendor/v1.0/tool.tar.gz"), []byte("tarball"), 0644)
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": srcDir,
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README", "vendor/v1.0/tool.tar.gz"}, objects)
+
+	target := path.Join(dstDir, "vendor/v1.0/tool.tar.gz")
+	err = dl.GetObject("vendor/v1.0/tool.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "tarball", string(content))
+}
+
+func TestFilesystemSymlinks(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "filesystem-src")
+	defer os.RemoveAll(srcDir)
+
+	os.MkdirAll(path.Join(srcDir, "export/vendor"), os.ModePerm)
+	ioutil.WriteFile(path.Join(srcDir, "export/vendor/tool.tar.gz"), []byte("tarball"), 0644)
+	os.Symlink(path.Join(srcDir, "export/vendor/tool.tar.gz"), path.Join(srcDir, "export/tool.tar.gz"))
+	os.Symlink(path.Join(srcDir, "missing"), path.Join(srcDir, "export/dangling"))
+	os.Symlink(path.Join(srcDir, "export/vendor"), path.Join(srcDir, "export/linked"))
+	os.Symlink(path.Join(srcDir, "export"), path.Join(srcDir, "root"))
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": path.Join(srcDir, "root"),
+		},
+	})
+	assert.NoError(t, err)
+
+	// The symlinked root is walked, the dangling symlink is skipped and the symlinked directory is not
+	// followed
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tool.tar.gz", "vendor/tool.tar.gz"}, objects)
+}
+
+func TestFilesystemMissingRoot(t *testing.T) {
+	dl, err := New(config.DownloaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": "/does/not/exist",
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.Error(t, err)
+}
+
+func TestFilesystemMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type:   "filesystem",
+		Config: map[interface{}]interface{}{},
+	})
+	assert.Equal(t, fmt.Errorf("configuration values cannot be empty: FilesystemRoot"), err)
+}
@@ -0,0 +1,161 @@
+package downloader
+
+import (
+	"crypto/tls"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// ftpDialTimeout is how long to wait when connecting to the FTP server
+const ftpDialTimeout = 30 * time.Second
+
+type ftpDownloaderConfig struct {
+	FtpHost               string `mapstructure:"ftp_host"`
+	FtpUser               string `mapstructure:"ftp_user"`
+	FtpPassword           string `mapstructure:"ftp_password"`
+	FtpPrefix             string `mapstructure:"ftp_prefix"`
+	FtpRecursive          bool   `mapstructure:"ftp_recursive"`
+	FtpDisableEPSV        bool   `mapstructure:"ftp_disable_epsv"`
+	FtpExplicitTLS        bool   `mapstructure:"ftp_explicit_tls"`
+	FtpInsecureSkipVerify bool   `mapstructure:"ftp_insecure_skip_verify"`
+}
+
+type ftpDownloader struct {
+	address   string
+	user      string
+	password  string
+	prefix    string
+	recursive bool
+	options   []ftp.DialOption
+}
+
+// newFTP returns an initialized ftpDownloader struct
+func newFTP(config config.DownloaderConfig) (Downloader, error) {
+	var cfg ftpDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFTPConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Default to the standard FTP port when only a host name is given
+	address := cfg.FtpHost
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "21")
+	}
+
+	// Anonymous login is used when no user is configured
+	user := cfg.FtpUser
+	password := cfg.FtpPassword
+	if user == "" {
+		user = "anonymous"
+		password = "anonymous"
+	}
+
+	options := []ftp.DialOption{
+		ftp.DialWithTimeout(ftpDialTimeout),
+		ftp.DialWithDisabledEPSV(cfg.FtpDisableEPSV),
+	}
+	if cfg.FtpExplicitTLS {
+		host, _, _ := net.SplitHostPort(address)
+		options = append(options, ftp.DialWithExplicitTLS(&tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: cfg.FtpInsecureSkipVerify,
+		}))
+	}
+
+	downloader := &ftpDownloader{
+		address:   address,
+		user:      user,
+		password:  password,
+		prefix:    path.Join("/", cfg.FtpPrefix),
+		recursive: cfg.FtpRecursive,
+		options:   options,
+	}
+
+	return downloader, nil
+}
+
+// validateFTPConfig validates the the configuration is not missing any required values
+func validateFTPConfig(cfg ftpDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"FtpHost": cfg.FtpHost,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// connect dials and logs in to the FTP server, the caller is responsible for calling Quit
+func (fd *ftpDownloader) connect() (*ftp.ServerConn, error) {
+	conn, err := ftp.Dial(fd.address, fd.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Login(fd.user, fd.password)
+	if err != nil {
+		conn.Quit()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// ListObjects lists the files available beneath the FTP prefix
+func (fd *ftpDownloader) ListObjects() ([]string, error) {
+	var objects []string
+
+	conn, err := fd.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Quit()
+
+	walker := conn.Walk(fd.prefix)
+	for walker.Next() {
+		entry := walker.Stat()
+
+		switch entry.Type {
+		case ftp.EntryTypeFolder:
+			if !fd.recursive {
+				walker.SkipDir()
+			}
+		case ftp.EntryTypeFile:
+			objects = append(objects, strings.TrimPrefix(walker.Path(), "/"))
+		}
+	}
+
+	err = walker.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (fd *ftpDownloader) GetObject(sourceObj string, targetPath string) error {
+	conn, err := fd.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Quit()
+
+	resp, err := conn.Retr(path.Join("/", sourceObj))
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	return writeObject(resp, targetPath)
+}
@@ -0,0 +1,243 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFTPServer is a minimal in-process FTP server supporting passive (EPSV) MLSD listings and RETR,
+// serving files from a map of paths without the leading slash
+type testFTPServer struct {
+	listener net.Listener
+	files    map[string]string
+
+	mu     sync.Mutex
+	logins []string
+}
+
+func newTestFTPServer(t *testing.T, files map[string]string) *testFTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	server := &testFTPServer{listener: listener, files: files}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *testFTPServer) Close() {
+	s.listener.Close()
+}
+
+// entries returns the MLSD lines of the directory's children, sorted by name
+func (s *testFTPServer) entries(dir string) []string {
+	dir = strings.Trim(dir, "/")
+	if dir != "" {
+		dir += "/"
+	}
+
+	children := map[string]string{}
+	for filePath, content := range s.files {
+		if !strings.HasPrefix(filePath, dir) {
+			continue
+		}
+		rest := strings.TrimPrefix(filePath, dir)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			children[rest[:i]] = "type=dir;"
+		} else {
+			children[rest] = fmt.Sprintf("type=file;size=%d;", len(content))
+		}
+	}
+
+	var lines []string
+	for name, facts := range children {
+		lines = append(lines, facts+" "+name)
+	}
+	sort.Strings(lines)
+
+	return lines
+}
+
+func (s *testFTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	c := textproto.NewConn(conn)
+	c.PrintfLine("220 ready")
+
+	var dataListener net.Listener
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			cmd, arg = line[:i], line[i+1:]
+		}
+
+		switch cmd {
+		case "USER":
+			s.mu.Lock()
+			s.logins = append(s.logins, arg)
+			s.mu.Unlock()
+			c.PrintfLine("331 password please")
+		case "PASS":
+			c.PrintfLine("230 logged in")
+		case "FEAT":
+			c.PrintfLine("211-Features:\r\n MLST type*;size*;\r\n211 End")
+		case "TYPE":
+			c.PrintfLine("200 ok")
+		case "EPSV":
+			dataListener, _ = net.Listen("tcp", "127.0.0.1:0")
+			c.PrintfLine("229 Entering Extended Passive Mode (|||%d|)", dataListener.Addr().(*net.TCPAddr).Port)
+		case "MLSD", "RETR":
+			content, isFile := s.files[strings.TrimPrefix(arg, "/")]
+			if cmd == "RETR" && !isFile {
+				dataListener.Close()
+				c.PrintfLine("550 %s not found", arg)
+				continue
+			}
+
+			dataConn, err := dataListener.Accept()
+			dataListener.Close()
+			if err != nil {
+				return
+			}
+			c.PrintfLine("150 opening data connection")
+			if cmd == "MLSD" {
+				for _, entry := range s.entries(arg) {
+					fmt.Fprintf(dataConn, "%s\r\n", entry)
+				}
+			} else {
+				fmt.Fprint(dataConn, content)
+			}
+			dataConn.Close()
+			c.PrintfLine("226 transfer complete")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("502 %s not implemented", cmd)
+		}
+	}
+}
+
+func testFTPFiles() map[string]string {
+	return map[string]string{
+		"pub/README":             "readme",
+		"pub/v1.0/tool.tar.gz":   "tarball",
+		"pub/v1.0/linux/tool.gz": "linux tarball",
+		"other/outside.txt":      "outside",
+	}
+}
+
+func TestFTPListObjectsRecursive(t *testing.T) {
+	server := newTestFTPServer(t, testFTPFiles())
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "ftp",
+		Config: map[interface{}]interface{}{
+			"ftp_host":      server.listener.Addr().String(),
+			"ftp_user":      "mirror",
+			"ftp_password":  "secret",
+			"ftp_prefix":    "pub/",
+			"ftp_recursive": true,
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pub/README", "pub/v1.0/tool.tar.gz", "pub/v1.0/linux/tool.gz"}, objects)
+	assert.Equal(t, []string{"mirror"}, server.logins)
+}
+
+func TestFTPListObjectsSkipsDirectories(t *testing.T) {
+	server := newTestFTPServer(t, testFTPFiles())
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "ftp",
+		Config: map[interface{}]interface{}{
+			"ftp_host":   server.listener.Addr().String(),
+			"ftp_prefix": "pub",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pub/README"}, objects)
+
+	// Anonymous login is used when no user is configured
+	assert.Equal(t, []string{"anonymous"}, server.logins)
+}
+
+func TestFTPGetObject(t *testing.T) {
+	server := newTestFTPServer(t, testFTPFiles())
+	defer server.Close()
+
+	tmpDir, _ := ioutil.TempDir("", "ftp")
+	defer os.RemoveAll(tmpDir)
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "ftp",
+		Config: map[interface{}]interface{}{
+			"ftp_host": server.listener.Addr().String(),
+		},
+	})
+	assert.NoError(t, err)
+
+	target := path.Join(tmpDir, "pub/v1.0/tool.tar.gz")
+	err = dl.GetObject("pub/v1.0/tool.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "tarball", string(content))
+
+	err = dl.GetObject("pub/missing.txt", path.Join(tmpDir, "pub/missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestFTPDefaultPort(t *testing.T) {
+	dl, err := New(config.DownloaderConfig{
+		Type: "ftp",
+		Config: map[interface{}]interface{}{
+			"ftp_host": "ftp.example.com",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ftp.example.com:21", dl.(*ftpDownloader).address)
+	assert.Equal(t, "/", dl.(*ftpDownloader).prefix)
+}
+
+func TestFTPMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "ftp",
+		Config: map[interface{}]interface{}{
+			"ftp_user": "mirror",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: FtpHost")
+}
@@ -0,0 +1,177 @@
+package downloader
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+)
+
+// defaultGcsEndpoint is the Google Cloud Storage JSON API endpoint used when gcs_endpoint is not set
+const defaultGcsEndpoint = "https://storage.googleapis.com"
+
+// gcsReadOnlyScope is the OAuth scope requested when authenticating with a service account
+const gcsReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+type gcsConfig struct {
+	GcsBucket          string `mapstructure:"gcs_bucket"`
+	GcsPrefix          string `mapstructure:"gcs_prefix"`
+	GcsCredentialsFile string `mapstructure:"gcs_credentials_file"`
+	GcsEndpoint        string `mapstructure:"gcs_endpoint"`
+}
+
+type gcs struct {
+	client    *http.Client
+	endpoint  string
+	gcsBucket string
+	gcsPrefix string
+}
+
+// gcsObjectList is the subset of the JSON API objects.list response that we use
+type gcsObjectList struct {
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func newGcs(config config.DownloaderConfig) (Downloader, error) {
+	var cfg gcsConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateGcsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := createGcsClient(cfg.GcsCredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := defaultGcsEndpoint
+	if cfg.GcsEndpoint != "" {
+		endpoint = strings.TrimSuffix(cfg.GcsEndpoint, "/")
+	}
+
+	downloader := gcs{
+		client:    client,
+		endpoint:  endpoint,
+		gcsBucket: cfg.GcsBucket,
+		gcsPrefix: cfg.GcsPrefix,
+	}
+
+	return &downloader, nil
+}
+
+func validateGcsConfig(cfg gcsConfig) error {
+	requiredConfigs := map[string]string{
+		"GcsBucket": cfg.GcsBucket,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// createGcsClient returns an HTTP client authenticated with the service account credentials file, or an
+// anonymous client for public buckets when no credentials file is given
+func createGcsClient(credentialsFile string) (*http.Client, error) {
+	if credentialsFile == "" {
+		return http.DefaultClient, nil
+	}
+
+	jsonKey, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON(jsonKey, gcsReadOnlyScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwtConfig.Client(context.Background()), nil
+}
+
+// objectURL builds the JSON API URL for the object, query is appended as-is
+func (gcss *gcs) objectURL(object string, query url.Values) string {
+	u := gcss.endpoint + "/storage/v1/b/" + url.PathEscape(gcss.gcsBucket) + "/o"
+	if object != "" {
+		u += "/" + url.PathEscape(object)
+	}
+	return u + "?" + query.Encode()
+}
+
+// ListObjects lists the objects available in the GCS bucket
+func (gcss *gcs) ListObjects() ([]string, error) {
+	var objects []string
+	pageToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("prefix", gcss.gcsPrefix)
+		query.Set("fields", "items(name),nextPageToken")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, gcss.objectURL("", query), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := httpGet(gcss.client, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var page gcsObjectList
+		err = json.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			// Skip the placeholder objects that the console creates for "folders"
+			if strings.HasSuffix(item.Name, "/") {
+				continue
+			}
+			objects = append(objects, item.Name)
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return objects, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (gcss *gcs) GetObject(sourceObj string, targetPath string) error {
+	query := url.Values{}
+	query.Set("alt", "media")
+
+	req, err := http.NewRequest(http.MethodGet, gcss.objectURL(sourceObj, query), nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := httpGet(gcss.client, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
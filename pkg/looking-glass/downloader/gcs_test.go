@@ -0,0 +1,91 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGcsServer fakes the parts of the GCS JSON API used by the gcs downloader, returning the
+// listing in two pages
+func newTestGcsServer(t *testing.T) *httptest.Server {
+	objects := map[string]string{
+		"vendor/a.tar.gz":   "a",
+		"vendor/b c.tar.gz": "b",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const listPath = "/storage/v1/b/test-bucket/o"
+
+		if r.URL.EscapedPath() == listPath {
+			assert.Equal(t, "vendor/", r.URL.Query().Get("prefix"))
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"items":[{"name":"vendor/"},{"name":"vendor/a.tar.gz"}],"nextPageToken":"page2"}`)
+			} else {
+				fmt.Fprint(w, `{"items":[{"name":"vendor/b c.tar.gz"}]}`)
+			}
+			return
+		}
+
+		if strings.HasPrefix(r.URL.EscapedPath(), listPath+"/") && r.URL.Query().Get("alt") == "media" {
+			// Object names must arrive as a single escaped path segment
+			escapedName := strings.TrimPrefix(r.URL.EscapedPath(), listPath+"/")
+			if !strings.Contains(escapedName, "/") {
+				if content, ok := objects[strings.TrimPrefix(r.URL.Path, listPath+"/")]; ok {
+					fmt.Fprint(w, content)
+					return
+				}
+			}
+		}
+
+		http.NotFound(w, r)
+	}))
+}
+
+func TestGcsListAndGetObjects(t *testing.T) {
+	server := newTestGcsServer(t)
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "gcs",
+		Config: map[interface{}]interface{}{
+			"gcs_bucket":   "test-bucket",
+			"gcs_prefix":   "vendor/",
+			"gcs_endpoint": server.URL,
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor/a.tar.gz", "vendor/b c.tar.gz"}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "gcs-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	target := path.Join(tmpDir, "vendor/b c.tar.gz")
+	err = dl.GetObject("vendor/b c.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(content))
+}
+
+func TestGcsMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "gcs",
+		Config: map[interface{}]interface{}{
+			"gcs_prefix": "vendor/",
+		},
+	})
+	assert.Equal(t, fmt.Errorf("configuration values cannot be empty: GcsBucket"), err)
+}
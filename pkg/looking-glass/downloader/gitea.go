@@ -0,0 +1,190 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// giteaPageSize is the page size requested when listing releases, it is the largest Gitea allows by default
+// but servers with a lower MAX_RESPONSE_ITEMS return smaller pages
+const giteaPageSize = 50
+
+type giteaDownloaderConfig struct {
+	GiteaURL   string `mapstructure:"gitea_url"`
+	GiteaRepo  string `mapstructure:"gitea_repo"`
+	GiteaToken string `mapstructure:"gitea_token"`
+}
+
+type giteaDownloader struct {
+	client    *gitea.Client
+	baseURL   string
+	token     string
+	repoOwner string
+	repoName  string
+}
+
+// newGitea returns an initialized giteaDownloader struct
+func newGitea(config config.DownloaderConfig) (Downloader, error) {
+	var cfg giteaDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateGiteaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repo is stored in the configuration as "owner/repo_name" so we split it out here
+	repo := strings.Split(cfg.GiteaRepo, "/")
+	if len(repo) != 2 {
+		return nil, fmt.Errorf("gitea_repo must be in the form of owner/repo_name: %s", cfg.GiteaRepo)
+	}
+
+	downloader := &giteaDownloader{
+		baseURL:   strings.TrimSuffix(cfg.GiteaURL, "/"),
+		token:     cfg.GiteaToken,
+		repoOwner: repo[0],
+		repoName:  repo[1],
+	}
+
+	return downloader, nil
+}
+
+// validateGiteaConfig validates the the configuration is not missing any required values
+func validateGiteaConfig(cfg giteaDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"GiteaURL":  cfg.GiteaURL,
+		"GiteaRepo": cfg.GiteaRepo,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// getClient returns the Gitea client, creating it on first use since the SDK contacts the server to
+// check its version
+func (gtd *giteaDownloader) getClient() (*gitea.Client, error) {
+	if gtd.client != nil {
+		return gtd.client, nil
+	}
+
+	var options []func(*gitea.Client)
+	if gtd.token != "" {
+		options = append(options, gitea.SetToken(gtd.token))
+	}
+
+	client, err := gitea.NewClient(gtd.baseURL, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	gtd.client = client
+	return gtd.client, nil
+}
+
+// buildObjectPath builds a path to an object that matches the Artifactory path
+func (gtd *giteaDownloader) buildObjectPath(tagName string, assetName string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", gtd.repoOwner, gtd.repoName, tagName, assetName)
+}
+
+// ListObjects lists the release attachments available in the Gitea repo
+func (gtd *giteaDownloader) ListObjects() ([]string, error) {
+	var objects []string
+
+	client, err := gtd.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	for page := 1; ; page++ {
+		releases, _, err := client.ListReleases(gtd.repoOwner, gtd.repoName, gitea.ListReleasesOptions{
+			ListOptions: gitea.ListOptions{Page: page, PageSize: giteaPageSize},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// A short page does not mean the last page, since the server may cap the page size
+		if len(releases) == 0 {
+			break
+		}
+
+		for _, release := range releases {
+			for _, attachment := range release.Attachments {
+				objects = append(objects, gtd.buildObjectPath(release.TagName, attachment.Name))
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// getAttachmentURL gets the download URL of the attachment for the given release tag
+func (gtd *giteaDownloader) getAttachmentURL(releaseTag string, assetName string) (string, error) {
+	client, err := gtd.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	// The client puts the tag into the URL as is, so a slash in it has to be escaped
+	release, _, err := client.GetReleaseByTag(gtd.repoOwner, gtd.repoName, url.PathEscape(releaseTag))
+	if err != nil {
+		return "", err
+	}
+
+	for _, attachment := range release.Attachments {
+		if attachment.Name == assetName {
+			return attachment.DownloadURL, nil
+		}
+	}
+	return "", fmt.Errorf("asset '%s' not found", assetName)
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (gtd *giteaDownloader) GetObject(sourceObj string, targetPath string) error {
+	// Tags may themselves contain slashes, while attachment names never do
+	tagAndName := strings.TrimPrefix(sourceObj, gtd.repoOwner+"/"+gtd.repoName+"/")
+	i := strings.LastIndex(tagAndName, "/")
+	if tagAndName == sourceObj || i <= 0 {
+		return fmt.Errorf("object '%s' is not in the form of owner/repo/tag/asset", sourceObj)
+	}
+	releaseTag, assetName := tagAndName[:i], tagAndName[i+1:]
+
+	downloadURL, err := gtd.getAttachmentURL(releaseTag, assetName)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+
+	// Attachments of private repos need the token, but never send it to another host
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return err
+	}
+	base, err := url.Parse(gtd.baseURL)
+	if err != nil {
+		return err
+	}
+	if gtd.token != "" && u.Host == base.Host {
+		req.Header.Set("Authorization", "token "+gtd.token)
+	}
+
+	body, err := httpGet(http.DefaultClient, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
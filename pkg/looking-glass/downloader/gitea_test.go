@@ -0,0 +1,138 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGiteaServer fakes the Gitea API for the repo owner/repo, capping pages at a single release like
+// a server with MAX_RESPONSE_ITEMS set to 1
+func newTestGiteaServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+
+	release := func(tag string, assets ...string) map[string]interface{} {
+		var attachments []map[string]string
+		for _, asset := range assets {
+			attachments = append(attachments, map[string]string{
+				"name":                 asset,
+				"browser_download_url": server.URL + "/attachments/" + tag + "/" + asset,
+			})
+		}
+		return map[string]interface{}{"tag_name": tag, "assets": attachments}
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		releases := []map[string]interface{}{
+			release("v2.0", "tool-linux.tar.gz", "tool-darwin.tar.gz"),
+			release("v1.0", "tool-linux.tar.gz"),
+			release("release/1.0", "tool.zip"),
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/version":
+			fmt.Fprint(w, `{"version":"1.13.0"}`)
+		case "/api/v1/repos/owner/repo/releases":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			assert.Equal(t, "50", r.URL.Query().Get("limit"))
+			if page >= 1 && page <= len(releases) {
+				json.NewEncoder(w).Encode(releases[page-1 : page])
+			} else {
+				fmt.Fprint(w, `[]`)
+			}
+		case "/api/v1/repos/owner/repo/releases/tags/v1.0":
+			json.NewEncoder(w).Encode(releases[1])
+		case "/api/v1/repos/owner/repo/releases/tags/release/1.0":
+			assert.Equal(t, "/api/v1/repos/owner/repo/releases/tags/release%2F1.0", r.URL.EscapedPath())
+			json.NewEncoder(w).Encode(releases[2])
+		case "/attachments/release/1.0/tool.zip":
+			fmt.Fprint(w, "zip")
+		case "/attachments/v1.0/tool-linux.tar.gz":
+			assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+			fmt.Fprint(w, "linux tarball")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return server
+}
+
+func TestGiteaListAndGetObjects(t *testing.T) {
+	server := newTestGiteaServer(t)
+	defer server.Close()
+
+	tmpDir, _ := ioutil.TempDir("", "gitea")
+	defer os.RemoveAll(tmpDir)
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "gitea",
+		Config: map[interface{}]interface{}{
+			"gitea_url":   server.URL + "/",
+			"gitea_repo":  "owner/repo",
+			"gitea_token": "secret",
+		},
+	})
+	assert.NoError(t, err)
+
+	// Every page is listed even though the server returns fewer releases than requested
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"owner/repo/v2.0/tool-linux.tar.gz",
+		"owner/repo/v2.0/tool-darwin.tar.gz",
+		"owner/repo/v1.0/tool-linux.tar.gz",
+		"owner/repo/release/1.0/tool.zip",
+	}, objects)
+
+	target := path.Join(tmpDir, "owner/repo/v1.0/tool-linux.tar.gz")
+	err = dl.GetObject("owner/repo/v1.0/tool-linux.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux tarball", string(content))
+
+	err = dl.GetObject("owner/repo/v1.0/missing.tar.gz", path.Join(tmpDir, "missing.tar.gz"))
+	assert.EqualError(t, err, "asset 'missing.tar.gz' not found")
+
+	// The tag is everything between the repo and the attachment name, even when it contains a slash
+	target = path.Join(tmpDir, "owner/repo/release/1.0/tool.zip")
+	err = dl.GetObject("owner/repo/release/1.0/tool.zip", target)
+	assert.NoError(t, err)
+
+	content, err = ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "zip", string(content))
+
+	err = dl.GetObject("other/repo/v1.0/tool-linux.tar.gz", path.Join(tmpDir, "other.tar.gz"))
+	assert.EqualError(t, err, "object 'other/repo/v1.0/tool-linux.tar.gz' is not in the form of owner/repo/tag/asset")
+}
+
+func TestGiteaBadRepo(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "gitea",
+		Config: map[interface{}]interface{}{
+			"gitea_url":  "https://gitea.example.com",
+			"gitea_repo": "repo",
+		},
+	})
+	assert.EqualError(t, err, "gitea_repo must be in the form of owner/repo_name: repo")
+}
+
+func TestGiteaMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type:   "gitea",
+		Config: map[interface{}]interface{}{},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: GiteaRepo, GiteaURL")
+}
@@ -15,15 +15,26 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// githubPageSize is the page size used when listing releases and tags, it is the largest GitHub allows
+const githubPageSize = 100
+
+// githubSourceDir is the directory beneath each tag holding its source archives, keeping them apart from
+// release assets which may have the same names
+const githubSourceDir = "source"
+
 type githubDownloaderConfig struct {
-	GithubRepo  string `mapstructure:"github_repo"`
-	GithubToken string `mapstructure:"github_token"`
+	GithubRepo           string `mapstructure:"github_repo"`
+	GithubToken          string `mapstructure:"github_token"`
+	GithubSourceArchives bool   `mapstructure:"github_source_archives"`
+	GithubTags           bool   `mapstructure:"github_tags"`
 }
 
 type githubDownloader struct {
-	client    *github.Client
-	repoOwner string
-	repoName  string
+	client         *github.Client
+	repoOwner      string
+	repoName       string
+	sourceArchives bool
+	tags           bool
 }
 
 // newGithub returns an initialized githubDownloader struct
@@ -45,9 +56,11 @@ func newGithub(config config.DownloaderConfig) (Downloader, error) {
 	repo := strings.Split(cfg.GithubRepo, "/")
 
 	downloader := &githubDownloader{
-		client:    client,
-		repoOwner: repo[0],
-		repoName:  repo[1],
+		client:         client,
+		repoOwner:      repo[0],
+		repoName:       repo[1],
+		sourceArchives: cfg.GithubSourceArchives,
+		tags:           cfg.GithubTags,
 	}
 
 	return downloader, nil
@@ -59,21 +72,7 @@ func validateGithubConfig(cfg githubDownloaderConfig) error {
 		"GithubRepo": cfg.GithubRepo,
 	}
 
-	var missingConfigs []string
-
-	// Check for configs that are not set
-	for cfgName, cfgValue := range requiredConfigs {
-		if cfgValue == "" {
-			missingConfigs = append(missingConfigs, cfgName)
-		}
-	}
-
-	// Error on all the missing configs
-	if len(missingConfigs) > 0 {
-		return fmt.Errorf("configuration values cannot be empty: %s", strings.Join(missingConfigs, ", "))
-	}
-
-	return nil
+	return config.CheckRequired(requiredConfigs)
 }
 
 // createGithubClient creates a new Github client to be used by the github downloader
@@ -95,18 +94,73 @@ func (ghd *githubDownloader) buildObjectPath(tagName string, assetName string) s
 	return fmt.Sprintf("%s/%s/%s/%s", ghd.repoOwner, ghd.repoName, tagName, assetName)
 }
 
+// buildSourceArchivePath builds the path to a source archive of the tag, beneath the tag's source directory
+func (ghd *githubDownloader) buildSourceArchivePath(tagName string, archiveName string) string {
+	return ghd.buildObjectPath(tagName, githubSourceDir+"/"+archiveName)
+}
+
+// sourceArchiveNames returns the names of the source tarball and zipball objects for the tag, any
+// slashes in the tag are replaced like GitHub does when naming the archives
+func (ghd *githubDownloader) sourceArchiveNames(tagName string) []string {
+	baseName := ghd.repoName + "-" + strings.Replace(tagName, "/", "-", -1)
+	return []string{baseName + ".tar.gz", baseName + ".zip"}
+}
+
+// listReleases lists every release in the Github Repo, following the pagination
+func (ghd *githubDownloader) listReleases() ([]*github.RepositoryRelease, error) {
+	var releases []*github.RepositoryRelease
+
+	ctx := context.Background()
+	opts := &github.ListOptions{PerPage: githubPageSize}
+	for {
+		page, resp, err := ghd.client.Repositories.ListReleases(ctx, ghd.repoOwner, ghd.repoName, opts)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, page...)
+
+		if resp.NextPage == 0 {
+			return releases, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listTags lists every tag in the Github Repo, following the pagination
+func (ghd *githubDownloader) listTags() ([]*github.RepositoryTag, error) {
+	var tags []*github.RepositoryTag
+
+	ctx := context.Background()
+	opts := &github.ListOptions{PerPage: githubPageSize}
+	for {
+		page, resp, err := ghd.client.Repositories.ListTags(ctx, ghd.repoOwner, ghd.repoName, opts)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+
+		if resp.NextPage == 0 {
+			return tags, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 // ListObjects lists the objects available in the Github Repo
 func (ghd *githubDownloader) ListObjects() ([]string, error) {
 	var objects []string
 
 	ctx := context.Background()
 
-	releases, _, err := ghd.client.Repositories.ListReleases(ctx, ghd.repoOwner, ghd.repoName, nil)
+	releases, err := ghd.listReleases()
 	if err != nil {
 		return nil, err
 	}
 
+	releaseTags := map[string]bool{}
 	for _, release := range releases {
+		releaseTags[*release.TagName] = true
+
 		assets, _, err := ghd.client.Repositories.ListReleaseAssets(ctx, ghd.repoOwner, ghd.repoName, *release.ID, nil)
 		if err != nil {
 			return nil, err
@@ -115,15 +169,76 @@ func (ghd *githubDownloader) ListObjects() ([]string, error) {
 		for _, asset := range assets {
 			objects = append(objects, ghd.buildObjectPath(*release.TagName, *asset.Name))
 		}
+
+		if ghd.sourceArchives {
+			for _, archiveName := range ghd.sourceArchiveNames(*release.TagName) {
+				objects = append(objects, ghd.buildSourceArchivePath(*release.TagName, archiveName))
+			}
+		}
+	}
+
+	// Tags without a release only have their source archives to mirror
+	if ghd.tags {
+		tags, err := ghd.listTags()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range tags {
+			if releaseTags[*tag.Name] {
+				continue
+			}
+			for _, archiveName := range ghd.sourceArchiveNames(*tag.Name) {
+				objects = append(objects, ghd.buildSourceArchivePath(*tag.Name, archiveName))
+			}
+		}
 	}
+
 	return objects, nil
 }
 
-// getReleaseID Gets the ID of a release from the release tag
-func (ghd *githubDownloader) getReleaseID(releaseTag string) (int64, error) {
+// getSourceArchiveURL gets the download URL of the source archive for the tag, in the format matching
+// the archive name
+func (ghd *githubDownloader) getSourceArchiveURL(tagName string, archiveName string) (string, error) {
 	ctx := context.Background()
 
-	releases, _, err := ghd.client.Repositories.ListReleases(ctx, ghd.repoOwner, ghd.repoName, nil)
+	format := github.Tarball
+	if strings.HasSuffix(archiveName, ".zip") {
+		format = github.Zipball
+	}
+
+	u, _, err := ghd.client.Repositories.GetArchiveLink(ctx, ghd.repoOwner, ghd.repoName, format, &github.RepositoryContentGetOptions{Ref: tagName}, false)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+// getSourceArchive downloads the source archive for the tag to the targetPath
+func (ghd *githubDownloader) getSourceArchive(tagName string, archiveName string, targetPath string) error {
+	archiveURL, err := ghd.getSourceArchiveURL(tagName, archiveName)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, archiveURL, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := httpGet(http.DefaultClient, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
+
+// getReleaseID Gets the ID of a release from the release tag
+func (ghd *githubDownloader) getReleaseID(releaseTag string) (int64, error) {
+	releases, err := ghd.listReleases()
 	if err != nil {
 		return -1, err
 	}
@@ -155,6 +270,24 @@ func (ghd *githubDownloader) getAssetID(releaseID int64, assetName string) (int6
 
 // GetObject downloads the object specified in sourceObj to the targetPath
 func (ghd *githubDownloader) GetObject(sourceObj string, targetPath string) error {
+	// Tags may themselves contain slashes, while asset and archive names never do
+	tagAndName := strings.TrimPrefix(sourceObj, ghd.repoOwner+"/"+ghd.repoName+"/")
+	i := strings.LastIndex(tagAndName, "/")
+	if i <= 0 {
+		return fmt.Errorf("invalid object '%s'", sourceObj)
+	}
+	releaseTag, assetName := tagAndName[:i], tagAndName[i+1:]
+
+	if ghd.sourceArchives || ghd.tags {
+		if tagName := strings.TrimSuffix(releaseTag, "/"+githubSourceDir); tagName != releaseTag {
+			for _, archiveName := range ghd.sourceArchiveNames(tagName) {
+				if assetName == archiveName {
+					return ghd.getSourceArchive(tagName, archiveName, targetPath)
+				}
+			}
+		}
+	}
+
 	// Ensure the temporary download path exists
 	err := os.MkdirAll(path.Dir(targetPath), os.ModePerm)
 	if err != nil {
@@ -166,13 +299,10 @@ func (ghd *githubDownloader) GetObject(sourceObj string, targetPath string) erro
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	// Identify the ReleaseID and AssetID
 	ctx := context.Background()
-	pathSplit := strings.Split(sourceObj, "/")
-
-	releaseTag := pathSplit[2]
-	assetName := pathSplit[3]
 
 	releaseID, err := ghd.getReleaseID(releaseTag)
 	if err != nil {
@@ -190,6 +320,7 @@ func (ghd *githubDownloader) GetObject(sourceObj string, targetPath string) erro
 	if err != nil {
 		return err
 	}
+	defer rc.Close()
 
 	_, err = io.Copy(f, rc)
 
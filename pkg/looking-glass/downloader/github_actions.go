@@ -0,0 +1,256 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// defaultGithubRetentionDays is the longest Github keeps artifacts for, runs created before then can only
+// have expired artifacts
+const defaultGithubRetentionDays = 90
+
+type githubActionsDownloaderConfig struct {
+	GithubRepo          string `mapstructure:"github_repo"`
+	GithubToken         string `mapstructure:"github_token"`
+	GithubWorkflow      string `mapstructure:"github_workflow"`
+	GithubBranch        string `mapstructure:"github_branch"`
+	GithubRetentionDays int    `mapstructure:"github_retention_days"`
+}
+
+// githubWorkflowRuns is a page of workflow runs, the version of go-github we use predates the Actions
+// runs and artifacts APIs so they are decoded here
+type githubWorkflowRuns struct {
+	WorkflowRuns []struct {
+		ID        int64 `json:"id"`
+		RunNumber int   `json:"run_number"`
+	} `json:"workflow_runs"`
+}
+
+// githubArtifacts is a page of a workflow run's artifacts
+type githubArtifacts struct {
+	Artifacts []struct {
+		ID      int64  `json:"id"`
+		Name    string `json:"name"`
+		Expired bool   `json:"expired"`
+	} `json:"artifacts"`
+}
+
+type githubActionsDownloader struct {
+	client        *github.Client
+	httpClient    *http.Client
+	repoOwner     string
+	repoName      string
+	workflow      string
+	branch        string
+	retentionDays int
+	objects       map[string]int64
+	// expiredRuns holds the runs known to only have expired artifacts, a finished run never gains new
+	// artifacts so they are not requested again
+	expiredRuns map[int64]bool
+}
+
+// newGithubActions returns an initialized githubActionsDownloader struct
+func newGithubActions(config config.DownloaderConfig) (Downloader, error) {
+	var cfg githubActionsDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateGithubActionsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repo is stored in the configuration as "owner/repo_name" so we split it out here
+	repo := strings.Split(cfg.GithubRepo, "/")
+	if len(repo) != 2 {
+		return nil, fmt.Errorf("github_repo must be in the form of owner/repo_name: %s", cfg.GithubRepo)
+	}
+
+	// Downloading artifacts always requires a token, even for public repos
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: cfg.GithubToken},
+	))
+
+	retentionDays := cfg.GithubRetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultGithubRetentionDays
+	}
+
+	downloader := &githubActionsDownloader{
+		client:        github.NewClient(httpClient),
+		httpClient:    httpClient,
+		repoOwner:     repo[0],
+		repoName:      repo[1],
+		workflow:      cfg.GithubWorkflow,
+		branch:        cfg.GithubBranch,
+		retentionDays: retentionDays,
+		objects:       map[string]int64{},
+		expiredRuns:   map[int64]bool{},
+	}
+
+	return downloader, nil
+}
+
+// validateGithubActionsConfig validates the the configuration is not missing any required values
+func validateGithubActionsConfig(cfg githubActionsDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"GithubRepo":     cfg.GithubRepo,
+		"GithubToken":    cfg.GithubToken,
+		"GithubWorkflow": cfg.GithubWorkflow,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// buildObjectPath builds a path to an object that matches the Artifactory path, run numbers are only
+// unique within a workflow so the path includes the workflow
+func (gad *githubActionsDownloader) buildObjectPath(runNumber int, artifactName string) string {
+	return fmt.Sprintf("%s/%s/%s/%d/%s.zip", gad.repoOwner, gad.repoName, gad.workflow, runNumber, artifactName)
+}
+
+// get requests the API path and decodes the response into v, returning the next page number
+func (gad *githubActionsDownloader) get(apiPath string, query url.Values, v interface{}) (int, error) {
+	req, err := gad.client.NewRequest(http.MethodGet, apiPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := gad.client.Do(context.Background(), req, v)
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.NextPage, nil
+}
+
+// ListObjects lists the unexpired artifacts of every successful run of the workflow created within the
+// retention period
+func (gad *githubActionsDownloader) ListObjects() ([]string, error) {
+	objects := map[string]int64{}
+
+	query := url.Values{}
+	query.Set("status", "success")
+	query.Set("per_page", "100")
+	query.Set("created", ">="+time.Now().UTC().AddDate(0, 0, -gad.retentionDays).Format("2006-01-02"))
+	if gad.branch != "" {
+		query.Set("branch", gad.branch)
+	}
+
+	runsPath := fmt.Sprintf("repos/%s/%s/actions/workflows/%s/runs", gad.repoOwner, gad.repoName, url.PathEscape(gad.workflow))
+	for page := 1; page != 0; {
+		query.Set("page", fmt.Sprint(page))
+
+		var runs githubWorkflowRuns
+		next, err := gad.get(runsPath, query, &runs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, run := range runs.WorkflowRuns {
+			if gad.expiredRuns[run.ID] {
+				continue
+			}
+
+			var artifacts githubArtifacts
+			artifactsPath := fmt.Sprintf("repos/%s/%s/actions/runs/%d/artifacts", gad.repoOwner, gad.repoName, run.ID)
+			_, err := gad.get(artifactsPath, url.Values{"per_page": {"100"}}, &artifacts)
+			if err != nil {
+				return nil, err
+			}
+
+			expired := true
+			for _, artifact := range artifacts.Artifacts {
+				if !artifact.Expired {
+					objects[gad.buildObjectPath(run.RunNumber, artifact.Name)] = artifact.ID
+					expired = false
+				}
+			}
+			if expired {
+				gad.expiredRuns[run.ID] = true
+			}
+		}
+
+		page = next
+	}
+	gad.objects = objects
+
+	var objectPaths []string
+	for objectPath := range objects {
+		objectPaths = append(objectPaths, objectPath)
+	}
+	sort.Strings(objectPaths)
+
+	return objectPaths, nil
+}
+
+// getArtifactURL gets the short lived download URL of the artifact's zip
+func (gad *githubActionsDownloader) getArtifactURL(artifactID int64) (string, error) {
+	req, err := gad.client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/actions/artifacts/%d/zip", gad.repoOwner, gad.repoName, artifactID), nil)
+	if err != nil {
+		return "", err
+	}
+
+	// The API redirects to blob storage, which must not receive our token, so stop at the redirect
+	noRedirectClient := *gad.httpClient
+	noRedirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := noRedirectClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", fmt.Errorf("GET %s returned %s", req.URL, resp.Status)
+	}
+
+	return resp.Header.Get("Location"), nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (gad *githubActionsDownloader) GetObject(sourceObj string, targetPath string) error {
+	artifactID, ok := gad.objects[sourceObj]
+	if !ok {
+		_, err := gad.ListObjects()
+		if err != nil {
+			return err
+		}
+
+		artifactID, ok = gad.objects[sourceObj]
+		if !ok {
+			return fmt.Errorf("artifact '%s' not found", sourceObj)
+		}
+	}
+
+	downloadURL, err := gad.getArtifactURL(artifactID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := httpGet(http.DefaultClient, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
@@ -0,0 +1,149 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubActionsListAndGetObjects(t *testing.T) {
+	artifactRequests := map[string]int{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/workflows/build.yml/runs":
+			assert.Equal(t, "success", r.URL.Query().Get("status"))
+			assert.Equal(t, "main", r.URL.Query().Get("branch"))
+			assert.Equal(t, ">="+time.Now().UTC().AddDate(0, 0, -30).Format("2006-01-02"), r.URL.Query().Get("created"))
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, r.URL.Path))
+				fmt.Fprint(w, `{"workflow_runs": [{"id": 100, "run_number": 7}]}`)
+			} else {
+				fmt.Fprint(w, `{"workflow_runs": [{"id": 90, "run_number": 6}]}`)
+			}
+		case "/repos/owner/repo/actions/runs/100/artifacts":
+			artifactRequests[r.URL.Path]++
+			fmt.Fprint(w, `{"artifacts": [{"id": 1, "name": "dist"}, {"id": 2, "name": "logs"}]}`)
+		case "/repos/owner/repo/actions/runs/90/artifacts":
+			artifactRequests[r.URL.Path]++
+			fmt.Fprint(w, `{"artifacts": [{"id": 3, "name": "dist", "expired": true}]}`)
+		case "/repos/owner/repo/actions/artifacts/1/zip":
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			http.Redirect(w, r, server.URL+"/blob/dist.zip", http.StatusFound)
+		case "/blob/dist.zip":
+			assert.Empty(t, r.Header.Get("Authorization"))
+			fmt.Fprint(w, "dist zip")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "github_actions",
+		Config: map[interface{}]interface{}{
+			"github_repo":           "owner/repo",
+			"github_token":          "secret",
+			"github_workflow":       "build.yml",
+			"github_branch":         "main",
+			"github_retention_days": 30,
+		},
+	})
+	assert.NoError(t, err)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	dl.(*githubActionsDownloader).client.BaseURL = baseURL
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owner/repo/build.yml/7/dist.zip", "owner/repo/build.yml/7/logs.zip"}, objects)
+
+	// Runs with only expired artifacts are not requested again
+	_, err = dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"/repos/owner/repo/actions/runs/100/artifacts": 2,
+		"/repos/owner/repo/actions/runs/90/artifacts":  1,
+	}, artifactRequests)
+
+	tmpDir, _ := ioutil.TempDir("", "github-actions-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("owner/repo/build.yml/7/dist.zip", path.Join(tmpDir, "dist.zip"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "dist.zip"))
+	assert.Equal(t, "dist zip", string(content))
+}
+
+func TestGithubActionsWorkflowsSharingRunNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/workflows/build.yml/runs":
+			fmt.Fprint(w, `{"workflow_runs": [{"id": 100, "run_number": 7}]}`)
+		case "/repos/owner/repo/actions/workflows/release.yml/runs":
+			fmt.Fprint(w, `{"workflow_runs": [{"id": 200, "run_number": 7}]}`)
+		case "/repos/owner/repo/actions/runs/100/artifacts":
+			fmt.Fprint(w, `{"artifacts": [{"id": 1, "name": "dist"}]}`)
+		case "/repos/owner/repo/actions/runs/200/artifacts":
+			fmt.Fprint(w, `{"artifacts": [{"id": 2, "name": "dist"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var objects []string
+	for _, workflow := range []string{"build.yml", "release.yml"} {
+		dl, err := New(config.DownloaderConfig{
+			Type: "github_actions",
+			Config: map[interface{}]interface{}{
+				"github_repo":     "owner/repo",
+				"github_token":    "secret",
+				"github_workflow": workflow,
+			},
+		})
+		assert.NoError(t, err)
+
+		baseURL, _ := url.Parse(server.URL + "/")
+		dl.(*githubActionsDownloader).client.BaseURL = baseURL
+
+		workflowObjects, err := dl.ListObjects()
+		assert.NoError(t, err)
+		objects = append(objects, workflowObjects...)
+	}
+
+	// Both workflows have a run 7 uploading dist, which are mirrored as different objects
+	assert.Equal(t, []string{"owner/repo/build.yml/7/dist.zip", "owner/repo/release.yml/7/dist.zip"}, objects)
+}
+
+func TestGithubActionsMissingConfig(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "github_actions",
+		Config: map[interface{}]interface{}{
+			"github_repo": "owner/repo",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: GithubToken, GithubWorkflow")
+}
+
+func TestGithubActionsDefaultRetention(t *testing.T) {
+	dl, err := New(config.DownloaderConfig{
+		Type: "github_actions",
+		Config: map[interface{}]interface{}{
+			"github_repo":     "owner/repo",
+			"github_token":    "secret",
+			"github_workflow": "build.yml",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 90, dl.(*githubActionsDownloader).retentionDays)
+}
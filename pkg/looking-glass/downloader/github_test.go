@@ -0,0 +1,132 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGithubServer fakes the parts of the Github API used by the github downloader, the releases are
+// returned one per page to exercise the pagination
+func newTestGithubServer() *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `[{"id": 2, "tag_name": "release/v1.0"}]`)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/tool/releases?page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id": 1, "tag_name": "v2.0"}]`)
+		case "/repos/owner/tool/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 10, "name": "tool.zip"}, {"id": 11, "name": "tool-v2.0.tar.gz"}]`)
+		case "/repos/owner/tool/releases/2/assets":
+			fmt.Fprint(w, `[]`)
+		case "/repos/owner/tool/releases/assets/10":
+			fmt.Fprint(w, "asset tool.zip")
+		case "/repos/owner/tool/releases/assets/11":
+			fmt.Fprint(w, "asset tool-v2.0.tar.gz")
+		case "/repos/owner/tool/tags":
+			fmt.Fprint(w, `[{"name": "v2.0"}, {"name": "release/v1.0"}, {"name": "v0.9"}]`)
+		case "/repos/owner/tool/tarball/v2.0":
+			http.Redirect(w, r, server.URL+"/archive/v2.0.tar.gz", http.StatusFound)
+		case "/archive/v2.0.tar.gz":
+			fmt.Fprint(w, "source v2.0")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return server
+}
+
+func newTestGithubDownloader(t *testing.T, server *httptest.Server, cfg map[interface{}]interface{}) Downloader {
+	cfg["github_repo"] = "owner/tool"
+	dl, err := New(config.DownloaderConfig{Type: "github", Config: cfg})
+	assert.NoError(t, err)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	dl.(*githubDownloader).client.BaseURL = baseURL
+
+	return dl
+}
+
+func TestGithubListObjects(t *testing.T) {
+	server := newTestGithubServer()
+	defer server.Close()
+
+	dl := newTestGithubDownloader(t, server, map[interface{}]interface{}{})
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"owner/tool/v2.0/tool.zip",
+		"owner/tool/v2.0/tool-v2.0.tar.gz",
+	}, objects)
+}
+
+func TestGithubListObjectsWithSourceArchives(t *testing.T) {
+	server := newTestGithubServer()
+	defer server.Close()
+
+	dl := newTestGithubDownloader(t, server, map[interface{}]interface{}{
+		"github_source_archives": true,
+		"github_tags":            true,
+	})
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"owner/tool/v2.0/tool.zip",
+		"owner/tool/v2.0/tool-v2.0.tar.gz",
+		"owner/tool/v2.0/source/tool-v2.0.tar.gz",
+		"owner/tool/v2.0/source/tool-v2.0.zip",
+		"owner/tool/release/v1.0/source/tool-release-v1.0.tar.gz",
+		"owner/tool/release/v1.0/source/tool-release-v1.0.zip",
+		"owner/tool/v0.9/source/tool-v0.9.tar.gz",
+		"owner/tool/v0.9/source/tool-v0.9.zip",
+	}, objects)
+}
+
+func TestGithubGetObject(t *testing.T) {
+	server := newTestGithubServer()
+	defer server.Close()
+
+	tmpDir, _ := ioutil.TempDir("", "github-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	dl := newTestGithubDownloader(t, server, map[interface{}]interface{}{
+		"github_source_archives": true,
+	})
+
+	// The release asset and the source archive have the same name but are different objects
+	err := dl.GetObject("owner/tool/v2.0/tool-v2.0.tar.gz", path.Join(tmpDir, "asset.tar.gz"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "asset.tar.gz"))
+	assert.Equal(t, "asset tool-v2.0.tar.gz", string(content))
+
+	err = dl.GetObject("owner/tool/v2.0/source/tool-v2.0.tar.gz", path.Join(tmpDir, "source.tar.gz"))
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadFile(path.Join(tmpDir, "source.tar.gz"))
+	assert.Equal(t, "source v2.0", string(content))
+
+	err = dl.GetObject("owner/tool/v3.0/tool.zip", path.Join(tmpDir, "missing.zip"))
+	assert.EqualError(t, err, "release 'v3.0' not found")
+}
+
+func TestGithubMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type:   "github",
+		Config: map[interface{}]interface{}{},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: GithubRepo")
+}
@@ -0,0 +1,302 @@
+package downloader
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/xanzy/go-gitlab"
+)
+
+// gitlabPerPage is the page size used when listing releases and packages
+const gitlabPerPage = 100
+
+type gitlabDownloaderConfig struct {
+	GitlabProject  string `mapstructure:"gitlab_project"`
+	GitlabToken    string `mapstructure:"gitlab_token"`
+	GitlabBaseURL  string `mapstructure:"gitlab_base_url"`
+	GitlabPackages bool   `mapstructure:"gitlab_packages"`
+}
+
+// gitlabObject records where an object returned by ListObjects is downloaded from, release links are
+// plain URLs while generic package files are fetched through the API
+type gitlabObject struct {
+	linkURL string
+	apiPath string
+}
+
+type gitlabDownloader struct {
+	client      *gitlab.Client
+	token       string
+	project     string
+	projectPath string
+	packages    bool
+	objects     map[string]gitlabObject
+}
+
+// newGitlab returns an initialized gitlabDownloader struct
+func newGitlab(config config.DownloaderConfig) (Downloader, error) {
+	var cfg gitlabDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateGitlabConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := createGitlabClient(cfg.GitlabToken, cfg.GitlabBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	downloader := &gitlabDownloader{
+		client:   client,
+		token:    cfg.GitlabToken,
+		project:  cfg.GitlabProject,
+		packages: cfg.GitlabPackages,
+		objects:  map[string]gitlabObject{},
+	}
+
+	return downloader, nil
+}
+
+// validateGitlabConfig validates the the configuration is not missing any required values
+func validateGitlabConfig(cfg gitlabDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"GitlabProject": cfg.GitlabProject,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// createGitlabClient creates a new Gitlab client to be used by the gitlab downloader
+func createGitlabClient(accessToken string, baseURL string) (*gitlab.Client, error) {
+	var options []gitlab.ClientOptionFunc
+	if baseURL != "" {
+		options = append(options, gitlab.WithBaseURL(baseURL))
+	}
+
+	return gitlab.NewClient(accessToken, options...)
+}
+
+// getProjectPath resolves the configured project, which may be a numeric ID, to its "namespace/project"
+// path so object paths are the same however the project is configured
+func (gld *gitlabDownloader) getProjectPath() (string, error) {
+	if gld.projectPath != "" {
+		return gld.projectPath, nil
+	}
+
+	project, _, err := gld.client.Projects.GetProject(gld.project, nil)
+	if err != nil {
+		return "", err
+	}
+
+	gld.projectPath = project.PathWithNamespace
+	return gld.projectPath, nil
+}
+
+// buildObjectPath builds a path to an object that matches the Artifactory path. Link names are free text
+// that path.Join would clean into a different path, so names that climb out of or start at the root are
+// rejected rather than joined
+func (gld *gitlabDownloader) buildObjectPath(projectPath string, elem ...string) (string, error) {
+	for _, e := range elem {
+		if strings.HasPrefix(e, "/") {
+			return "", fmt.Errorf("unsafe name '%s'", e)
+		}
+		for _, segment := range strings.Split(e, "/") {
+			if segment == ".." {
+				return "", fmt.Errorf("unsafe name '%s'", e)
+			}
+		}
+	}
+
+	return path.Join(append([]string{projectPath}, elem...)...), nil
+}
+
+// listReleaseObjects lists the asset links of every release in the project
+func (gld *gitlabDownloader) listReleaseObjects(projectPath string, objects map[string]gitlabObject) error {
+	opt := &gitlab.ListReleasesOptions{PerPage: gitlabPerPage}
+	for {
+		releases, resp, err := gld.client.Releases.ListReleases(gld.project, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, release := range releases {
+			for _, link := range release.Assets.Links {
+				linkURL := link.DirectAssetURL
+				if linkURL == "" {
+					linkURL = link.URL
+				}
+				objectPath, err := gld.buildObjectPath(projectPath, release.TagName, link.Name)
+				if err != nil {
+					log.Printf("WARN: Skipping asset link of release %s - %s", release.TagName, err)
+					continue
+				}
+				objects[objectPath] = gitlabObject{linkURL: linkURL}
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// listPackageObjects lists the files of every generic package in the project's package registry
+func (gld *gitlabDownloader) listPackageObjects(projectPath string, objects map[string]gitlabObject) error {
+	opt := &gitlab.ListProjectPackagesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: gitlabPerPage},
+		PackageType: gitlab.String("generic"),
+	}
+	for {
+		pkgs, resp, err := gld.client.Packages.ListProjectPackages(gld.project, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, pkg := range pkgs {
+			fileOpt := &gitlab.ListPackageFilesOptions{PerPage: gitlabPerPage}
+			for {
+				files, fileResp, err := gld.client.Packages.ListPackageFiles(gld.project, pkg.ID, fileOpt)
+				if err != nil {
+					return err
+				}
+
+				for _, file := range files {
+					apiPath := fmt.Sprintf("projects/%s/packages/generic/%s/%s/%s",
+						url.PathEscape(gld.project),
+						url.PathEscape(pkg.Name),
+						url.PathEscape(pkg.Version),
+						url.PathEscape(file.FileName))
+					objectPath, err := gld.buildObjectPath(projectPath, "packages", pkg.Name, pkg.Version, file.FileName)
+					if err != nil {
+						log.Printf("WARN: Skipping file of package %s - %s", pkg.Name, err)
+						continue
+					}
+					objects[objectPath] = gitlabObject{apiPath: apiPath}
+				}
+
+				if fileResp.NextPage == 0 {
+					break
+				}
+				fileOpt.Page = fileResp.NextPage
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// ListObjects lists the release asset links, and optionally generic package files, in the Gitlab project
+func (gld *gitlabDownloader) ListObjects() ([]string, error) {
+	projectPath, err := gld.getProjectPath()
+	if err != nil {
+		return nil, err
+	}
+
+	objects := map[string]gitlabObject{}
+
+	err = gld.listReleaseObjects(projectPath, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	if gld.packages {
+		err = gld.listPackageObjects(projectPath, objects)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gld.objects = objects
+
+	var objectPaths []string
+	for objectPath := range objects {
+		objectPaths = append(objectPaths, objectPath)
+	}
+	sort.Strings(objectPaths)
+
+	return objectPaths, nil
+}
+
+// getObjectSource looks up where sourceObj is downloaded from, refreshing the listing if it is not known
+func (gld *gitlabDownloader) getObjectSource(sourceObj string) (gitlabObject, error) {
+	obj, ok := gld.objects[sourceObj]
+	if !ok {
+		_, err := gld.ListObjects()
+		if err != nil {
+			return gitlabObject{}, err
+		}
+
+		obj, ok = gld.objects[sourceObj]
+		if !ok {
+			return gitlabObject{}, fmt.Errorf("object '%s' not found", sourceObj)
+		}
+	}
+
+	return obj, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (gld *gitlabDownloader) GetObject(sourceObj string, targetPath string) error {
+	obj, err := gld.getObjectSource(sourceObj)
+	if err != nil {
+		return err
+	}
+
+	if obj.apiPath != "" {
+		req, err := gld.client.NewRequest(http.MethodGet, obj.apiPath, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		f, err := createTargetFile(targetPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = gld.client.Do(req, f)
+		if err != nil {
+			return err
+		}
+
+		return f.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, obj.linkURL, nil)
+	if err != nil {
+		return err
+	}
+
+	// Only send the token to the Gitlab instance, links can point at external hosts
+	linkURL, err := url.Parse(obj.linkURL)
+	if err != nil {
+		return err
+	}
+	if gld.token != "" && linkURL.Host == gld.client.BaseURL().Host {
+		req.Header.Set("PRIVATE-TOKEN", gld.token)
+	}
+
+	body, err := httpGet(http.DefaultClient, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
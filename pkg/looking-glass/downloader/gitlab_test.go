@@ -0,0 +1,109 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGitlabServer fakes the parts of the Gitlab API used by the gitlab downloader for project 42
+func newTestGitlabServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/api/v4/projects/42", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":42,"path_with_namespace":"group/sub/tool"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/42/releases", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"tag_name":"v1.0","assets":{"links":[
+			{"name":"tool-linux.tar.gz","url":"%s/downloads/tool-linux.tar.gz"},
+			{"name":"../../../other/x","url":"%s/downloads/x"},
+			{"name":"/etc/passwd","url":"%s/downloads/passwd"}]}}]`, server.URL, server.URL, server.URL)
+	})
+	mux.HandleFunc("/api/v4/projects/42/packages", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "generic", r.URL.Query().Get("package_type"))
+		fmt.Fprint(w, `[{"id":7,"name":"tool","version":"1.0.0","package_type":"generic"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/42/packages/7/package_files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"package_id":7,"file_name":"tool.bin"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/42/packages/generic/tool/1.0.0/tool.bin", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-token", r.Header.Get("PRIVATE-TOKEN"))
+		fmt.Fprint(w, "package file")
+	})
+	mux.HandleFunc("/downloads/tool-linux.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-token", r.Header.Get("PRIVATE-TOKEN"))
+		fmt.Fprint(w, "release asset")
+	})
+
+	return server
+}
+
+func TestGitlabListAndGetObjects(t *testing.T) {
+	server := newTestGitlabServer(t)
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "gitlab",
+		Config: map[interface{}]interface{}{
+			"gitlab_project":  "42",
+			"gitlab_token":    "my-token",
+			"gitlab_base_url": server.URL,
+			"gitlab_packages": true,
+		},
+	})
+	assert.NoError(t, err)
+
+	// Links whose names would be cleaned into another path are skipped
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"group/sub/tool/packages/tool/1.0.0/tool.bin",
+		"group/sub/tool/v1.0/tool-linux.tar.gz",
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "gitlab-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	for obj, expected := range map[string]string{
+		"group/sub/tool/packages/tool/1.0.0/tool.bin": "package file",
+		"group/sub/tool/v1.0/tool-linux.tar.gz":       "release asset",
+	} {
+		target := path.Join(tmpDir, obj)
+		err = dl.GetObject(obj, target)
+		assert.NoError(t, err)
+
+		content, err := ioutil.ReadFile(target)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+}
+
+func TestGitlabBuildObjectPath(t *testing.T) {
+	gld := &gitlabDownloader{}
+
+	objectPath, err := gld.buildObjectPath("group/tool", "v1.0", "tool..tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, "group/tool/v1.0/tool..tar.gz", objectPath)
+
+	_, err = gld.buildObjectPath("group/tool", "v1.0", "../../x")
+	assert.EqualError(t, err, "unsafe name '../../x'")
+
+	_, err = gld.buildObjectPath("group/tool", "v1.0", "/x")
+	assert.EqualError(t, err, "unsafe name '/x'")
+}
+
+func TestGitlabMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type:   "gitlab",
+		Config: map[interface{}]interface{}{},
+	})
+	assert.Equal(t, fmt.Errorf("configuration values cannot be empty: GitlabProject"), err)
+}
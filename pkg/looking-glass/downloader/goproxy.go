@@ -0,0 +1,209 @@
+package downloader
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// defaultGoproxyURL is the proxy used when goproxy_url is not set
+const defaultGoproxyURL = "https://proxy.golang.org/"
+
+// goproxyListFile is the file listing a module's versions, relative to the module path
+const goproxyListFile = "@v/list"
+
+// goproxyVersionExtensions are the files served by the proxy for each version of a module
+var goproxyVersionExtensions = []string{".info", ".mod", ".zip"}
+
+type goproxyDownloaderConfig struct {
+	GoproxyURL      string   `mapstructure:"goproxy_url"`
+	GoproxyModules  []string `mapstructure:"goproxy_modules"`
+	GoproxyUsername string   `mapstructure:"goproxy_username"`
+	GoproxyPassword string   `mapstructure:"goproxy_password"`
+}
+
+type goproxyDownloader struct {
+	client   *http.Client
+	proxyURL *url.URL
+	modules  []string
+	username string
+	password string
+	lists    map[string][]string
+}
+
+// newGoproxy returns an initialized goproxyDownloader struct
+func newGoproxy(config config.DownloaderConfig) (Downloader, error) {
+	var cfg goproxyDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateGoproxyConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rawURL := cfg.GoproxyURL
+	if rawURL == "" {
+		rawURL = defaultGoproxyURL
+	}
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The proxy may be hosted beneath a path, so make sure module paths resolve beneath it
+	if !strings.HasSuffix(proxyURL.Path, "/") {
+		proxyURL.Path += "/"
+	}
+
+	downloader := &goproxyDownloader{
+		client:   http.DefaultClient,
+		proxyURL: proxyURL,
+		modules:  cfg.GoproxyModules,
+		username: cfg.GoproxyUsername,
+		password: cfg.GoproxyPassword,
+		lists:    map[string][]string{},
+	}
+
+	return downloader, nil
+}
+
+// validateGoproxyConfig validates the the configuration is not missing any required values
+func validateGoproxyConfig(cfg goproxyDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"GoproxyModules": strings.Join(cfg.GoproxyModules, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// escapeGoproxyPath applies the proxy protocol's case encoding to a module path or version, replacing
+// every upper case letter with an exclamation mark followed by its lower case letter
+func escapeGoproxyPath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// newRequest builds a GET request for the object path relative to the proxy URL
+func (gpd *goproxyDownloader) newRequest(objectPath string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, gpd.proxyURL.ResolveReference(&url.URL{Path: objectPath}).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if gpd.username != "" {
+		req.SetBasicAuth(gpd.username, gpd.password)
+	}
+
+	return req, nil
+}
+
+// listVersions lists the versions of the module from its @v/list
+func (gpd *goproxyDownloader) listVersions(module string) ([]string, error) {
+	req, err := gpd.newRequest(escapeGoproxyPath(module) + "/" + goproxyListFile)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := httpGet(gpd.client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var versions []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if version := strings.TrimSpace(scanner.Text()); version != "" {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, scanner.Err()
+}
+
+// ListObjects lists the .info, .mod and .zip of every version of the configured modules, followed by
+// each module's @v/list, using the escaped paths of the proxy protocol
+func (gpd *goproxyDownloader) ListObjects() ([]string, error) {
+	var objects []string
+	lists := map[string][]string{}
+
+	for _, module := range gpd.modules {
+		versions, err := gpd.listVersions(module)
+		if err != nil {
+			return nil, err
+		}
+
+		modulePath := escapeGoproxyPath(module)
+		for _, version := range versions {
+			for _, ext := range goproxyVersionExtensions {
+				objects = append(objects, fmt.Sprintf("%s/@v/%s%s", modulePath, escapeGoproxyPath(version), ext))
+			}
+		}
+
+		// The list goes after the versions it references
+		objects = append(objects, modulePath+"/"+goproxyListFile)
+		lists[modulePath+"/"+goproxyListFile] = versions
+	}
+	gpd.lists = lists
+
+	return objects, nil
+}
+
+// IsMutable reports whether the object is a module's version list, which has to be mirrored on every
+// pass
+func (gpd *goproxyDownloader) IsMutable(sourceObj string) bool {
+	return strings.HasSuffix(sourceObj, "/"+goproxyListFile)
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (gpd *goproxyDownloader) GetObject(sourceObj string, targetPath string) error {
+	if gpd.IsMutable(sourceObj) {
+		versions, ok := gpd.lists[sourceObj]
+		if !ok {
+			_, err := gpd.ListObjects()
+			if err != nil {
+				return err
+			}
+
+			versions, ok = gpd.lists[sourceObj]
+			if !ok {
+				return fmt.Errorf("version list '%s' not found", sourceObj)
+			}
+		}
+
+		var list strings.Builder
+		for _, version := range versions {
+			list.WriteString(version + "\n")
+		}
+		return writeObject(strings.NewReader(list.String()), targetPath)
+	}
+
+	req, err := gpd.newRequest(sourceObj)
+	if err != nil {
+		return err
+	}
+
+	body, err := httpGet(gpd.client, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
@@ -0,0 +1,105 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoproxyListAndGetObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/proxy/github.com/!burnt!sushi/toml/@v/list":
+			fmt.Fprint(w, "v0.3.0\nv0.3.1\n")
+		case "/proxy/github.com/!burnt!sushi/toml/@v/v0.3.1.mod":
+			fmt.Fprint(w, "module github.com/BurntSushi/toml\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "goproxy",
+		Config: map[interface{}]interface{}{
+			"goproxy_url":     server.URL + "/proxy",
+			"goproxy_modules": []interface{}{"github.com/BurntSushi/toml"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"github.com/!burnt!sushi/toml/@v/v0.3.0.info",
+		"github.com/!burnt!sushi/toml/@v/v0.3.0.mod",
+		"github.com/!burnt!sushi/toml/@v/v0.3.0.zip",
+		"github.com/!burnt!sushi/toml/@v/v0.3.1.info",
+		"github.com/!burnt!sushi/toml/@v/v0.3.1.mod",
+		"github.com/!burnt!sushi/toml/@v/v0.3.1.zip",
+		"github.com/!burnt!sushi/toml/@v/list",
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "goproxy-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("github.com/!burnt!sushi/toml/@v/v0.3.1.mod", path.Join(tmpDir, "v0.3.1.mod"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "v0.3.1.mod"))
+	assert.Equal(t, "module github.com/BurntSushi/toml\n", string(content))
+
+	assert.True(t, dl.(MutableDownloader).IsMutable("github.com/!burnt!sushi/toml/@v/list"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("github.com/!burnt!sushi/toml/@v/v0.3.1.zip"))
+}
+
+func TestGoproxyListFromListing(t *testing.T) {
+	list := "v0.3.0\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/!burnt!sushi/toml/@v/list" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, list)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "goproxy",
+		Config: map[interface{}]interface{}{
+			"goproxy_url":     server.URL,
+			"goproxy_modules": []interface{}{"github.com/BurntSushi/toml"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.NoError(t, err)
+
+	// A version published after the listing is not referenced by the mirrored list
+	list = "v0.3.0\nv0.4.0\n"
+
+	tmpDir, _ := ioutil.TempDir("", "goproxy-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("github.com/!burnt!sushi/toml/@v/list", path.Join(tmpDir, "list"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "list"))
+	assert.Equal(t, "v0.3.0\n", string(content))
+}
+
+func TestGoproxyMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "goproxy",
+		Config: map[interface{}]interface{}{
+			"goproxy_username": "mirror",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: GoproxyModules")
+}
@@ -0,0 +1,252 @@
+package downloader
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// defaultHashicorpURL is the releases site used when hashicorp_url is not set
+const defaultHashicorpURL = "https://releases.hashicorp.com/"
+
+type hashicorpDownloaderConfig struct {
+	HashicorpURL       string   `mapstructure:"hashicorp_url"`
+	HashicorpProducts  []string `mapstructure:"hashicorp_products"`
+	HashicorpPlatforms []string `mapstructure:"hashicorp_platforms"`
+}
+
+// hashicorpIndex is the subset of a product's index.json listing each version's files
+type hashicorpIndex struct {
+	Versions map[string]struct {
+		Shasums          string `json:"shasums"`
+		ShasumsSignature string `json:"shasums_signature"`
+		Builds           []struct {
+			OS       string `json:"os"`
+			Arch     string `json:"arch"`
+			Filename string `json:"filename"`
+			URL      string `json:"url"`
+		} `json:"builds"`
+	} `json:"versions"`
+}
+
+// hashicorpObject is a mirrored release file, builds remember their SHA256SUMS so they can be verified
+type hashicorpObject struct {
+	url     string
+	shasums string
+}
+
+type hashicorpDownloader struct {
+	client    *http.Client
+	baseURL   *url.URL
+	products  []string
+	platforms map[string]bool
+	objects   map[string]hashicorpObject
+}
+
+// newHashicorp returns an initialized hashicorpDownloader struct
+func newHashicorp(config config.DownloaderConfig) (Downloader, error) {
+	var cfg hashicorpDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateHashicorpConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rawURL := cfg.HashicorpURL
+	if rawURL == "" {
+		rawURL = defaultHashicorpURL
+	}
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The base URL is a directory, so make sure product paths resolve beneath it
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	platforms := map[string]bool{}
+	for _, platform := range cfg.HashicorpPlatforms {
+		platforms[platform] = true
+	}
+
+	downloader := &hashicorpDownloader{
+		client:    http.DefaultClient,
+		baseURL:   baseURL,
+		products:  cfg.HashicorpProducts,
+		platforms: platforms,
+		objects:   map[string]hashicorpObject{},
+	}
+
+	return downloader, nil
+}
+
+// validateHashicorpConfig validates the the configuration is not missing any required values
+func validateHashicorpConfig(cfg hashicorpDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"HashicorpProducts": strings.Join(cfg.HashicorpProducts, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// get requests a URL from the index, which may be relative to the base URL
+func (hcd *hashicorpDownloader) get(rawURL string) (io.ReadCloser, error) {
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, hcd.baseURL.ResolveReference(ref).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpGet(hcd.client, req)
+}
+
+// getIndex fetches and parses the index.json of the product
+func (hcd *hashicorpDownloader) getIndex(product string) (*hashicorpIndex, error) {
+	body, err := hcd.get(product + "/index.json")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var index hashicorpIndex
+	err = json.NewDecoder(body).Decode(&index)
+	if err != nil {
+		return nil, err
+	}
+
+	return &index, nil
+}
+
+// ListObjects lists the zips of the selected platforms for every version of the configured products,
+// along with each version's SHA256SUMS and its signature, as product/version/filename
+func (hcd *hashicorpDownloader) ListObjects() ([]string, error) {
+	objects := map[string]hashicorpObject{}
+
+	for _, product := range hcd.products {
+		index, err := hcd.getIndex(product)
+		if err != nil {
+			return nil, err
+		}
+
+		for version, release := range index.Versions {
+			versionPath := path.Join(product, version)
+
+			var builds int
+			for _, build := range release.Builds {
+				if len(hcd.platforms) > 0 && !hcd.platforms[build.OS+"_"+build.Arch] {
+					continue
+				}
+
+				buildURL := build.URL
+				if buildURL == "" {
+					buildURL = path.Join(versionPath, build.Filename)
+				}
+				object := hashicorpObject{url: buildURL}
+				if release.Shasums != "" {
+					object.shasums = path.Join(versionPath, release.Shasums)
+				}
+				objects[path.Join(versionPath, build.Filename)] = object
+				builds++
+			}
+
+			// Only mirror the checksums of versions that have a build for one of the selected platforms
+			if builds == 0 {
+				continue
+			}
+			for _, file := range []string{release.Shasums, release.ShasumsSignature} {
+				if file != "" {
+					objects[path.Join(versionPath, file)] = hashicorpObject{url: path.Join(versionPath, file)}
+				}
+			}
+		}
+	}
+	hcd.objects = objects
+
+	var objectPaths []string
+	for objectPath := range objects {
+		objectPaths = append(objectPaths, objectPath)
+	}
+	sort.Strings(objectPaths)
+
+	return objectPaths, nil
+}
+
+// getChecksum looks up the SHA256 checksum of the file in the SHA256SUMS at shasumsPath
+func (hcd *hashicorpDownloader) getChecksum(shasumsPath string, filename string) ([]byte, error) {
+	body, err := hcd.get(shasumsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == filename {
+			return hex.DecodeString(fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("no checksum for '%s' in %s", filename, shasumsPath)
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath, verifying builds against
+// the release's SHA256SUMS
+func (hcd *hashicorpDownloader) GetObject(sourceObj string, targetPath string) error {
+	object, ok := hcd.objects[sourceObj]
+	if !ok {
+		_, err := hcd.ListObjects()
+		if err != nil {
+			return err
+		}
+
+		object, ok = hcd.objects[sourceObj]
+		if !ok {
+			return fmt.Errorf("release file '%s' not found", sourceObj)
+		}
+	}
+
+	var expected []byte
+	if object.shasums != "" {
+		var err error
+		expected, err = hcd.getChecksum(object.shasums, path.Base(sourceObj))
+		if err != nil {
+			return err
+		}
+	}
+
+	body, err := hcd.get(object.url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if expected == nil {
+		return writeObject(body, targetPath)
+	}
+	return writeVerifiedObject(body, targetPath, sha256.New(), expected)
+}
@@ -0,0 +1,88 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashicorpListAndGetObjects(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/releases/tool/index.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name": "tool", "versions": {
+"1.0.0": {
+  "shasums": "tool_1.0.0_SHA256SUMS",
+  "shasums_signature": "tool_1.0.0_SHA256SUMS.sig",
+  "builds": [
+    {"os": "linux", "arch": "amd64", "filename": "tool_1.0.0_linux_amd64.zip", "url": "%s/releases/tool/1.0.0/tool_1.0.0_linux_amd64.zip"},
+    {"os": "darwin", "arch": "amd64", "filename": "tool_1.0.0_darwin_amd64.zip"}
+  ]
+},
+"0.9.0": {
+  "shasums": "tool_0.9.0_SHA256SUMS",
+  "builds": [{"os": "windows", "arch": "386", "filename": "tool_0.9.0_windows_386.zip"}]
+}
+}}`, server.URL)
+	})
+	mux.HandleFunc("/releases/tool/1.0.0/tool_1.0.0_SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  tool_1.0.0_linux_amd64.zip\n")
+		fmt.Fprint(w, "0000000000000000000000000000000000000000000000000000000000000000  tool_1.0.0_darwin_amd64.zip\n")
+	})
+	mux.HandleFunc("/releases/tool/1.0.0/", func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == "tool_1.0.0_darwin_amd64.zip" {
+			fmt.Fprint(w, "corrupt")
+		}
+	})
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "hashicorp",
+		Config: map[interface{}]interface{}{
+			"hashicorp_url":       server.URL + "/releases",
+			"hashicorp_products":  []interface{}{"tool"},
+			"hashicorp_platforms": []interface{}{"linux_amd64", "darwin_amd64"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"tool/1.0.0/tool_1.0.0_SHA256SUMS",
+		"tool/1.0.0/tool_1.0.0_SHA256SUMS.sig",
+		"tool/1.0.0/tool_1.0.0_darwin_amd64.zip",
+		"tool/1.0.0/tool_1.0.0_linux_amd64.zip",
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "hashicorp-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("tool/1.0.0/tool_1.0.0_linux_amd64.zip", path.Join(tmpDir, "linux.zip"))
+	assert.NoError(t, err)
+	err = dl.GetObject("tool/1.0.0/tool_1.0.0_darwin_amd64.zip", path.Join(tmpDir, "darwin.zip"))
+	assert.EqualError(t, err, "checksum mismatch for darwin.zip")
+
+	err = dl.GetObject("tool/1.0.0/tool_1.0.0_SHA256SUMS", path.Join(tmpDir, "SHA256SUMS"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "SHA256SUMS"))
+	assert.Contains(t, string(content), "tool_1.0.0_linux_amd64.zip")
+}
+
+func TestHashicorpMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "hashicorp",
+		Config: map[interface{}]interface{}{
+			"hashicorp_platforms": []string{"linux_amd64"},
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: HashicorpProducts")
+}
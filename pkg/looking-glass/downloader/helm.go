@@ -0,0 +1,275 @@
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"gopkg.in/yaml.v2"
+)
+
+// helmIndexObject is the name of the regenerated index.yaml in the mirror
+const helmIndexObject = "index.yaml"
+
+type helmDownloaderConfig struct {
+	HelmURL      string   `mapstructure:"helm_url"`
+	HelmCharts   []string `mapstructure:"helm_charts"`
+	HelmUsername string   `mapstructure:"helm_username"`
+	HelmPassword string   `mapstructure:"helm_password"`
+}
+
+// helmIndex is a chart repository index.yaml, chart versions are kept as generic maps so every field
+// survives being regenerated
+type helmIndex struct {
+	APIVersion string                              `yaml:"apiVersion"`
+	Entries    map[string][]map[string]interface{} `yaml:"entries"`
+	Extra      map[string]interface{}              `yaml:",inline"`
+}
+
+type helmDownloader struct {
+	client   *http.Client
+	repoURL  *url.URL
+	charts   map[string]bool
+	username string
+	password string
+	objects  map[string]string
+	index    *helmIndex
+}
+
+// newHelm returns an initialized helmDownloader struct
+func newHelm(config config.DownloaderConfig) (Downloader, error) {
+	var cfg helmDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateHelmConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repoURL, err := url.Parse(cfg.HelmURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repo URL is a directory, so make sure relative chart URLs resolve beneath it
+	if !strings.HasSuffix(repoURL.Path, "/") {
+		repoURL.Path += "/"
+	}
+
+	charts := map[string]bool{}
+	for _, chart := range cfg.HelmCharts {
+		charts[chart] = true
+	}
+
+	downloader := &helmDownloader{
+		client:   http.DefaultClient,
+		repoURL:  repoURL,
+		charts:   charts,
+		username: cfg.HelmUsername,
+		password: cfg.HelmPassword,
+		objects:  map[string]string{},
+	}
+
+	return downloader, nil
+}
+
+// validateHelmConfig validates the the configuration is not missing any required values
+func validateHelmConfig(cfg helmDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"HelmURL": cfg.HelmURL,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// get requests the URL, only sending credentials to the chart repository's own host
+func (hd *helmDownloader) get(u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if hd.username != "" && u.Host == hd.repoURL.Host {
+		req.SetBasicAuth(hd.username, hd.password)
+	}
+
+	return httpGet(hd.client, req)
+}
+
+// getIndex fetches and parses the repository's index.yaml
+func (hd *helmDownloader) getIndex() (*helmIndex, error) {
+	body, err := hd.get(hd.repoURL.ResolveReference(&url.URL{Path: "index.yaml"}))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var index helmIndex
+	err = yaml.Unmarshal(content, &index)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the charts we were not asked to mirror
+	if len(hd.charts) > 0 {
+		for name := range index.Entries {
+			if !hd.charts[name] {
+				delete(index.Entries, name)
+			}
+		}
+	}
+
+	return &index, nil
+}
+
+// chartObjectPath returns the object path for a chart URL, keeping its path when it lives beneath the
+// repo URL and otherwise using just the file name
+func (hd *helmDownloader) chartObjectPath(chartURL *url.URL) string {
+	if chartURL.Host == hd.repoURL.Host && strings.HasPrefix(chartURL.Path, hd.repoURL.Path) {
+		return strings.TrimPrefix(chartURL.Path, hd.repoURL.Path)
+	}
+	return path.Base(chartURL.Path)
+}
+
+// chartURLs returns the resolved URLs of the chart version, which may be relative to the repo URL
+func (hd *helmDownloader) chartURLs(version map[string]interface{}) []*url.URL {
+	var urls []*url.URL
+
+	rawURLs, _ := version["urls"].([]interface{})
+	for _, rawURL := range rawURLs {
+		s, ok := rawURL.(string)
+		if !ok {
+			continue
+		}
+
+		ref, err := url.Parse(s)
+		if err != nil {
+			continue
+		}
+		urls = append(urls, hd.repoURL.ResolveReference(ref))
+	}
+
+	return urls
+}
+
+// ListObjects lists every chart version's archive, followed by the regenerated index.yaml
+func (hd *helmDownloader) ListObjects() ([]string, error) {
+	index, err := hd.getIndex()
+	if err != nil {
+		return nil, err
+	}
+	hd.index = index
+
+	objects := map[string]string{}
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			urls := hd.chartURLs(version)
+			if len(urls) == 0 {
+				continue
+			}
+			objects[hd.chartObjectPath(urls[0])] = urls[0].String()
+		}
+	}
+	hd.objects = objects
+
+	var objectPaths []string
+	for objectPath := range objects {
+		objectPaths = append(objectPaths, objectPath)
+	}
+	sort.Strings(objectPaths)
+
+	// The index is regenerated from the charts listed here, so it goes after them
+	return append(objectPaths, helmIndexObject), nil
+}
+
+// IsMutable reports whether the object is the index, which has to be mirrored on every pass
+func (hd *helmDownloader) IsMutable(sourceObj string) bool {
+	return sourceObj == helmIndexObject
+}
+
+// regenerateIndex returns the index.yaml from the last listing with every chart URL pointing at its
+// mirrored object
+func (hd *helmDownloader) regenerateIndex() ([]byte, error) {
+	if hd.index == nil {
+		_, err := hd.ListObjects()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Copy the chart versions, so the listed index keeps its upstream URLs
+	index := helmIndex{
+		APIVersion: hd.index.APIVersion,
+		Entries:    map[string][]map[string]interface{}{},
+		Extra:      hd.index.Extra,
+	}
+	for name, versions := range hd.index.Entries {
+		for _, version := range versions {
+			regenerated := map[string]interface{}{}
+			for key, value := range version {
+				regenerated[key] = value
+			}
+
+			urls := hd.chartURLs(version)
+			if len(urls) > 0 {
+				regenerated["urls"] = []string{hd.chartObjectPath(urls[0])}
+			}
+			index.Entries[name] = append(index.Entries[name], regenerated)
+		}
+	}
+
+	return yaml.Marshal(index)
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (hd *helmDownloader) GetObject(sourceObj string, targetPath string) error {
+	if sourceObj == helmIndexObject {
+		content, err := hd.regenerateIndex()
+		if err != nil {
+			return err
+		}
+		return writeObject(bytes.NewReader(content), targetPath)
+	}
+
+	chartURL, ok := hd.objects[sourceObj]
+	if !ok {
+		_, err := hd.ListObjects()
+		if err != nil {
+			return err
+		}
+
+		chartURL, ok = hd.objects[sourceObj]
+		if !ok {
+			return fmt.Errorf("chart '%s' not found", sourceObj)
+		}
+	}
+
+	u, err := url.Parse(chartURL)
+	if err != nil {
+		return err
+	}
+
+	body, err := hd.get(u)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
@@ -0,0 +1,160 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+// newTestChartRepo serves a chart repository under /charts/ whose index mixes relative, absolute and
+// external chart URLs
+func newTestChartRepo() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/charts/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `apiVersion: v1
+entries:
+  app:
+  - name: app
+    version: 1.0.0
+    digest: abc
+    urls:
+    - app-1.0.0.tgz
+  - name: app
+    version: 1.1.0
+    urls:
+    - %[1]s/charts/packages/app-1.1.0.tgz
+  other:
+  - name: other
+    version: 0.1.0
+    urls:
+    - %[1]s/releases/download/other-0.1.0.tgz
+generated: "2020-01-01T00:00:00Z"
+`, server.URL)
+	})
+	mux.HandleFunc("/charts/app-1.0.0.tgz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "app 1.0.0")
+	})
+	mux.HandleFunc("/releases/download/other-0.1.0.tgz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "other 0.1.0")
+	})
+
+	return server
+}
+
+func TestHelmListAndGetObjects(t *testing.T) {
+	server := newTestChartRepo()
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "helm",
+		Config: map[interface{}]interface{}{
+			"helm_url": server.URL + "/charts",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app-1.0.0.tgz", "other-0.1.0.tgz", "packages/app-1.1.0.tgz", "index.yaml"}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "helm-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("other-0.1.0.tgz", path.Join(tmpDir, "other-0.1.0.tgz"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "other-0.1.0.tgz"))
+	assert.Equal(t, "other 0.1.0", string(content))
+
+	err = dl.GetObject("index.yaml", path.Join(tmpDir, "index.yaml"))
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadFile(path.Join(tmpDir, "index.yaml"))
+
+	var index helmIndex
+	assert.NoError(t, yaml.Unmarshal(content, &index))
+	assert.Equal(t, []interface{}{"app-1.0.0.tgz"}, index.Entries["app"][0]["urls"])
+	assert.Equal(t, "abc", index.Entries["app"][0]["digest"])
+	assert.Equal(t, []interface{}{"packages/app-1.1.0.tgz"}, index.Entries["app"][1]["urls"])
+	assert.Equal(t, []interface{}{"other-0.1.0.tgz"}, index.Entries["other"][0]["urls"])
+	assert.Equal(t, "2020-01-01T00:00:00Z", index.Extra["generated"])
+
+	assert.True(t, dl.(MutableDownloader).IsMutable("index.yaml"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("app-1.0.0.tgz"))
+}
+
+func TestHelmChartsFilter(t *testing.T) {
+	server := newTestChartRepo()
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "helm",
+		Config: map[interface{}]interface{}{
+			"helm_url":    server.URL + "/charts/",
+			"helm_charts": []interface{}{"other"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other-0.1.0.tgz", "index.yaml"}, objects)
+}
+
+func TestHelmIndexFromListing(t *testing.T) {
+	index := `apiVersion: v1
+entries:
+  app:
+  - name: app
+    version: 1.0.0
+    urls:
+    - app-1.0.0.tgz
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, index)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "helm",
+		Config: map[interface{}]interface{}{
+			"helm_url": server.URL,
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app-1.0.0.tgz", "index.yaml"}, objects)
+
+	// A chart published upstream during the pass must not appear in the mirrored index
+	index += `  - name: app
+    version: 1.1.0
+    urls:
+    - app-1.1.0.tgz
+`
+
+	tmpDir, _ := ioutil.TempDir("", "helm-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	for i := 0; i < 2; i++ {
+		err = dl.GetObject("index.yaml", path.Join(tmpDir, "index.yaml"))
+		assert.NoError(t, err)
+		content, _ := ioutil.ReadFile(path.Join(tmpDir, "index.yaml"))
+
+		var mirrored helmIndex
+		assert.NoError(t, yaml.Unmarshal(content, &mirrored))
+		if assert.Len(t, mirrored.Entries["app"], 1) {
+			assert.Equal(t, "1.0.0", mirrored.Entries["app"][0]["version"])
+			assert.Equal(t, []interface{}{"app-1.0.0.tgz"}, mirrored.Entries["app"][0]["urls"])
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package downloader
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"golang.org/x/net/html"
+)
+
+// defaultHTTPMaxDepth is how many directories deep the index is crawled when max_depth is not set
+const defaultHTTPMaxDepth = 5
+
+type httpDownloaderConfig struct {
+	URL      string `mapstructure:"url"`
+	MaxDepth *int   `mapstructure:"max_depth"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type httpDownloader struct {
+	client   *http.Client
+	baseURL  *url.URL
+	maxDepth int
+	username string
+	password string
+}
+
+// newHTTP returns an initialized httpDownloader struct
+func newHTTP(config config.DownloaderConfig) (Downloader, error) {
+	var cfg httpDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateHTTPConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index is a directory, so make sure relative links resolve beneath it
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	maxDepth := defaultHTTPMaxDepth
+	if cfg.MaxDepth != nil {
+		maxDepth = *cfg.MaxDepth
+	}
+
+	downloader := &httpDownloader{
+		client:   http.DefaultClient,
+		baseURL:  baseURL,
+		maxDepth: maxDepth,
+		username: cfg.Username,
+		password: cfg.Password,
+	}
+
+	return downloader, nil
+}
+
+// validateHTTPConfig validates the the configuration is not missing any required values
+func validateHTTPConfig(cfg httpDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"URL": cfg.URL,
+	}
+
+	err := config.CheckRequired(requiredConfigs)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MaxDepth != nil && *cfg.MaxDepth < 0 {
+		return fmt.Errorf("max_depth cannot be negative")
+	}
+
+	return nil
+}
+
+// httpGet requests the URL and returns the response body, failing on any non-2xx status
+func httpGet(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned %s", req.URL, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// get requests the URL using the configured credentials
+func (hd *httpDownloader) get(u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if hd.username != "" {
+		req.SetBasicAuth(hd.username, hd.password)
+	}
+
+	return httpGet(hd.client, req)
+}
+
+// parseIndexLinks returns the href of every anchor in the index page
+func parseIndexLinks(r io.Reader) ([]string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, attr := range n.Attr {
+				if attr.Key == "href" {
+					links = append(links, attr.Val)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return links, nil
+}
+
+// relativePath returns the path of u relative to the base URL, and false if u is not beneath it
+func (hd *httpDownloader) relativePath(u *url.URL) (string, bool) {
+	if u.Scheme != hd.baseURL.Scheme || u.Host != hd.baseURL.Host {
+		return "", false
+	}
+
+	// Skip sorting links (?C=N;O=D), anchors and anything outside of the base URL
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", false
+	}
+	if !strings.HasPrefix(u.Path, hd.baseURL.Path) || u.Path == hd.baseURL.Path {
+		return "", false
+	}
+
+	return strings.TrimPrefix(u.Path, hd.baseURL.Path), true
+}
+
+// crawl lists the files linked from the index at dir, descending into subdirectories up to maxDepth
+func (hd *httpDownloader) crawl(dir *url.URL, depth int, seen map[string]bool) ([]string, error) {
+	body, err := hd.get(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	links, err := parseIndexLinks(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []string
+	for _, link := range links {
+		ref, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+
+		u := dir.ResolveReference(ref)
+		relPath, ok := hd.relativePath(u)
+		if !ok || seen[relPath] {
+			continue
+		}
+		seen[relPath] = true
+
+		if strings.HasSuffix(relPath, "/") {
+			if depth >= hd.maxDepth {
+				continue
+			}
+			subObjects, err := hd.crawl(u, depth+1, seen)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, subObjects...)
+		} else {
+			objects = append(objects, relPath)
+		}
+	}
+
+	return objects, nil
+}
+
+// ListObjects lists the files linked from the directory index
+func (hd *httpDownloader) ListObjects() ([]string, error) {
+	return hd.crawl(hd.baseURL, 0, map[string]bool{})
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (hd *httpDownloader) GetObject(sourceObj string, targetPath string) error {
+	u := *hd.baseURL
+	u.Path = path.Join(hd.baseURL.Path, sourceObj)
+	u.RawPath = ""
+
+	body, err := hd.get(&u)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return writeObject(body, targetPath)
+}
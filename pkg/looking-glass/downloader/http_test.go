@@ -0,0 +1,124 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestIndexServer serves a small autoindex style tree beneath /pub/
+func newTestIndexServer() *httptest.Server {
+	pages := map[string]string{
+		"/pub/": `<html><body><h1>Index of /pub</h1>
+<a href="?C=N;O=D">Name</a>
+<a href="../">Parent Directory</a>
+<a href="tool-1.0.tar.gz">tool-1.0.tar.gz</a>
+<a href="linux/">linux/</a>
+<a href="/other/outside.txt">outside.txt</a>
+<a href="http://example.com/pub/elsewhere.txt">elsewhere.txt</a>
+</body></html>`,
+		"/pub/linux/": `<html><body>
+<a href="/pub/">Parent Directory</a>
+<a href="tool%20linux.bin">tool linux.bin</a>
+<a href="amd64/">amd64/</a>
+</body></html>`,
+		"/pub/linux/amd64/": `<html><body>
+<a href="deep.bin">deep.bin</a>
+</body></html>`,
+	}
+	files := map[string]string{
+		"/pub/tool-1.0.tar.gz":      "tarball",
+		"/pub/linux/tool linux.bin": "linux binary",
+		"/pub/linux/amd64/deep.bin": "deep binary",
+		"/other/outside.txt":        "outside",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if page, ok := pages[r.URL.Path]; ok {
+			fmt.Fprint(w, page)
+			return
+		}
+		if file, ok := files[r.URL.Path]; ok {
+			fmt.Fprint(w, file)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+func TestHTTPListObjects(t *testing.T) {
+	server := newTestIndexServer()
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "http",
+		Config: map[interface{}]interface{}{
+			"url": server.URL + "/pub",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tool-1.0.tar.gz", "linux/tool linux.bin", "linux/amd64/deep.bin"}, objects)
+}
+
+func TestHTTPListObjectsMaxDepth(t *testing.T) {
+	server := newTestIndexServer()
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "http",
+		Config: map[interface{}]interface{}{
+			"url":       server.URL + "/pub/",
+			"max_depth": 1,
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tool-1.0.tar.gz", "linux/tool linux.bin"}, objects)
+}
+
+func TestHTTPGetObject(t *testing.T) {
+	server := newTestIndexServer()
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "http",
+		Config: map[interface{}]interface{}{
+			"url": server.URL + "/pub/",
+		},
+	})
+	assert.NoError(t, err)
+
+	tmpDir, _ := ioutil.TempDir("", "http-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	target := path.Join(tmpDir, "linux/tool linux.bin")
+	err = dl.GetObject("linux/tool linux.bin", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux binary", string(content))
+
+	err = dl.GetObject("missing.bin", path.Join(tmpDir, "missing.bin"))
+	assert.Error(t, err)
+}
+
+func TestHTTPMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type:   "http",
+		Config: map[interface{}]interface{}{},
+	})
+	assert.Equal(t, fmt.Errorf("configuration values cannot be empty: URL"), err)
+}
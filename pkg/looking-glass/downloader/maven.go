@@ -0,0 +1,366 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// defaultMavenURL is the repository used when maven_url is not set
+const defaultMavenURL = "https://repo1.maven.org/maven2/"
+
+// mavenMetadataFile is the name of the metadata file listing an artifact's versions
+const mavenMetadataFile = "maven-metadata.xml"
+
+// mavenFileSuffixes are appended to artifactId-version to build the files mirrored for each version
+var mavenFileSuffixes = []string{".pom", ".jar", "-sources.jar"}
+
+// mavenChecksumExtensions are the checksum files published next to every file in a repository
+var mavenChecksumExtensions = []string{".sha1", ".md5"}
+
+type mavenDownloaderConfig struct {
+	MavenURL       string   `mapstructure:"maven_url"`
+	MavenArtifacts []string `mapstructure:"maven_artifacts"`
+	MavenUsername  string   `mapstructure:"maven_username"`
+	MavenPassword  string   `mapstructure:"maven_password"`
+}
+
+// mavenMetadata is the subset of maven-metadata.xml listing the versions of an artifact
+type mavenMetadata struct {
+	Versions []string `xml:"versioning>versions>version"`
+}
+
+type mavenDownloader struct {
+	client    *http.Client
+	repoURL   *url.URL
+	artifacts []string
+	username  string
+	password  string
+	exists    map[string]bool
+	metadata  map[string][]byte
+}
+
+// newMaven returns an initialized mavenDownloader struct
+func newMaven(config config.DownloaderConfig) (Downloader, error) {
+	var cfg mavenDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateMavenConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rawURL := cfg.MavenURL
+	if rawURL == "" {
+		rawURL = defaultMavenURL
+	}
+	repoURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repo URL is a directory, so make sure artifact paths resolve beneath it
+	if !strings.HasSuffix(repoURL.Path, "/") {
+		repoURL.Path += "/"
+	}
+
+	// Coordinates are stored in the configuration as "groupId:artifactId" so we turn them into the
+	// repository path here
+	var artifacts []string
+	for _, coordinates := range cfg.MavenArtifacts {
+		parts := strings.Split(coordinates, ":")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("maven_artifacts must be in the form of groupId:artifactId: %s", coordinates)
+		}
+		artifacts = append(artifacts, path.Join(strings.Replace(parts[0], ".", "/", -1), parts[1]))
+	}
+
+	downloader := &mavenDownloader{
+		client:    http.DefaultClient,
+		repoURL:   repoURL,
+		artifacts: artifacts,
+		username:  cfg.MavenUsername,
+		password:  cfg.MavenPassword,
+		exists:    map[string]bool{},
+		metadata:  map[string][]byte{},
+	}
+
+	return downloader, nil
+}
+
+// validateMavenConfig validates the the configuration is not missing any required values
+func validateMavenConfig(cfg mavenDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"MavenArtifacts": strings.Join(cfg.MavenArtifacts, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// newRequest builds a request for the object path relative to the repo URL
+func (md *mavenDownloader) newRequest(method string, objectPath string) (*http.Request, error) {
+	req, err := http.NewRequest(method, md.repoURL.ResolveReference(&url.URL{Path: objectPath}).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if md.username != "" {
+		req.SetBasicAuth(md.username, md.password)
+	}
+
+	return req, nil
+}
+
+// getMetadata fetches the maven-metadata.xml of the artifact, returning it along with its parsed versions
+func (md *mavenDownloader) getMetadata(artifact string) ([]byte, *mavenMetadata, error) {
+	req, err := md.newRequest(http.MethodGet, path.Join(artifact, mavenMetadataFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := httpGet(md.client, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var metadata mavenMetadata
+	err = xml.Unmarshal(content, &metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, &metadata, nil
+}
+
+// objectExists checks whether the object is published, not every version has every file so they have
+// to be probed, released files never change so a published file is remembered, while a missing one is
+// probed again as it may be published later
+func (md *mavenDownloader) objectExists(objectPath string) (bool, error) {
+	if md.exists[objectPath] {
+		return true, nil
+	}
+
+	req, err := md.newRequest(http.MethodHead, objectPath)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := md.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		md.exists[objectPath] = true
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("HEAD %s returned %s", req.URL, resp.Status)
+	}
+}
+
+// mavenWithChecksums returns the object path followed by the paths of all of its checksum files
+func mavenWithChecksums(objectPath string) []string {
+	objectPaths := []string{objectPath}
+	for _, ext := range mavenChecksumExtensions {
+		objectPaths = append(objectPaths, objectPath+ext)
+	}
+	return objectPaths
+}
+
+// ListObjects lists the pom, jar and sources jar of every version of the configured artifacts along
+// with their checksum files, followed by each artifact's maven-metadata.xml
+func (md *mavenDownloader) ListObjects() ([]string, error) {
+	var objects []string
+	metadataFiles := map[string][]byte{}
+
+	for _, artifact := range md.artifacts {
+		content, metadata, err := md.getMetadata(artifact)
+		if err != nil {
+			return nil, err
+		}
+		metadataFiles[path.Join(artifact, mavenMetadataFile)] = content
+
+		artifactID := path.Base(artifact)
+		for _, version := range metadata.Versions {
+			for _, suffix := range mavenFileSuffixes {
+				objectPath := path.Join(artifact, version, artifactID+"-"+version+suffix)
+
+				exists, err := md.objectExists(objectPath)
+				if err != nil {
+					return nil, err
+				}
+				if !exists {
+					continue
+				}
+				objects = append(objects, objectPath)
+
+				// Not every repository publishes every checksum, e.g. Gradle only publishes .sha256 and .sha512
+				for _, ext := range mavenChecksumExtensions {
+					exists, err = md.objectExists(objectPath + ext)
+					if err != nil {
+						return nil, err
+					}
+					if exists {
+						objects = append(objects, objectPath+ext)
+					}
+				}
+			}
+		}
+
+		// The metadata goes after the versions it references
+		objects = append(objects, mavenWithChecksums(path.Join(artifact, mavenMetadataFile))...)
+	}
+	md.metadata = metadataFiles
+
+	return objects, nil
+}
+
+// IsMutable reports whether the object is an artifact's metadata or one of its checksums, which have
+// to be mirrored on every pass
+func (md *mavenDownloader) IsMutable(sourceObj string) bool {
+	for _, ext := range append([]string{""}, mavenChecksumExtensions...) {
+		if path.Base(sourceObj) == mavenMetadataFile+ext {
+			return true
+		}
+	}
+	return false
+}
+
+// getListedMetadata writes the metadata kept from the last listing, or its checksum computed from it so
+// the checksum always matches the mirrored metadata, reporting false if sourceObj is neither
+func (md *mavenDownloader) getListedMetadata(sourceObj string, targetPath string) (bool, error) {
+	checksums := map[string]func() hash.Hash{
+		"":      nil,
+		".sha1": sha1.New,
+		".md5":  md5.New,
+	}
+
+	for ext, newHash := range checksums {
+		if path.Base(sourceObj) != mavenMetadataFile+ext {
+			continue
+		}
+
+		metadataPath := strings.TrimSuffix(sourceObj, ext)
+		content, ok := md.metadata[metadataPath]
+		if !ok {
+			_, err := md.ListObjects()
+			if err != nil {
+				return true, err
+			}
+
+			content, ok = md.metadata[metadataPath]
+			if !ok {
+				return true, fmt.Errorf("metadata '%s' not found", metadataPath)
+			}
+		}
+
+		if newHash != nil {
+			h := newHash()
+			h.Write(content)
+			content = []byte(hex.EncodeToString(h.Sum(nil)))
+		}
+		return true, writeObject(bytes.NewReader(content), targetPath)
+	}
+
+	return false, nil
+}
+
+// getSHA1 fetches the published .sha1 of the object, reporting false if there is none
+func (md *mavenDownloader) getSHA1(objectPath string) ([]byte, bool, error) {
+	req, err := md.newRequest(http.MethodGet, objectPath+".sha1")
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := md.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, false, fmt.Errorf("GET %s returned %s", req.URL, resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Some repositories follow the checksum with the file name like sha1sum does
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return nil, false, fmt.Errorf("empty checksum %s", req.URL)
+	}
+	expected, err := hex.DecodeString(fields[0])
+	if err != nil || len(expected) != sha1.Size {
+		return nil, false, fmt.Errorf("invalid checksum %s", req.URL)
+	}
+
+	return expected, true, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath, verifying files against
+// their published .sha1
+func (md *mavenDownloader) GetObject(sourceObj string, targetPath string) error {
+	if ok, err := md.getListedMetadata(sourceObj, targetPath); ok {
+		return err
+	}
+
+	var expected []byte
+	isChecksum := false
+	for _, ext := range mavenChecksumExtensions {
+		isChecksum = isChecksum || strings.HasSuffix(sourceObj, ext)
+	}
+	if !isChecksum {
+		var err error
+		expected, _, err = md.getSHA1(sourceObj)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := md.newRequest(http.MethodGet, sourceObj)
+	if err != nil {
+		return err
+	}
+
+	body, err := httpGet(md.client, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if expected == nil {
+		return writeObject(body, targetPath)
+	}
+	return writeVerifiedObject(body, targetPath, sha1.New(), expected)
+}
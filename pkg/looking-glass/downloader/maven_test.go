@@ -0,0 +1,174 @@
+package downloader
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSHA1 returns the hex encoded sha1 of the content
+func testSHA1(content string) string {
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestMavenListAndGetObjects(t *testing.T) {
+	files := map[string]string{
+		"/maven2/com/example/sdk/maven-metadata.xml": `<metadata>
+  <groupId>com.example</groupId>
+  <artifactId>sdk</artifactId>
+  <versioning>
+    <versions>
+      <version>1.0</version>
+      <version>1.1</version>
+    </versions>
+  </versioning>
+</metadata>`,
+		"/maven2/com/example/sdk/1.0/sdk-1.0.pom":              "pom 1.0",
+		"/maven2/com/example/sdk/1.1/sdk-1.1.pom":              "pom 1.1",
+		"/maven2/com/example/sdk/1.1/sdk-1.1.pom.sha1":         testSHA1("pom 1.1"),
+		"/maven2/com/example/sdk/1.1/sdk-1.1.jar":              "jar 1.1",
+		"/maven2/com/example/sdk/1.1/sdk-1.1.jar.sha1":         testSHA1("jar 1.1") + "  sdk-1.1.jar",
+		"/maven2/com/example/sdk/1.1/sdk-1.1.jar.md5":          "md5",
+		"/maven2/com/example/sdk/1.1/sdk-1.1-sources.jar":      "sources 1.1",
+		"/maven2/com/example/sdk/1.1/sdk-1.1-sources.jar.sha1": testSHA1("corrupt"),
+	}
+
+	heads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads++
+		}
+
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "maven",
+		Config: map[interface{}]interface{}{
+			"maven_url":       server.URL + "/maven2",
+			"maven_artifacts": []interface{}{"com.example:sdk"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"com/example/sdk/1.0/sdk-1.0.pom",
+		"com/example/sdk/1.1/sdk-1.1.pom",
+		"com/example/sdk/1.1/sdk-1.1.pom.sha1",
+		"com/example/sdk/1.1/sdk-1.1.jar",
+		"com/example/sdk/1.1/sdk-1.1.jar.sha1",
+		"com/example/sdk/1.1/sdk-1.1.jar.md5",
+		"com/example/sdk/1.1/sdk-1.1-sources.jar",
+		"com/example/sdk/1.1/sdk-1.1-sources.jar.sha1",
+		"com/example/sdk/maven-metadata.xml",
+		"com/example/sdk/maven-metadata.xml.sha1",
+		"com/example/sdk/maven-metadata.xml.md5",
+	}, objects)
+	assert.Equal(t, 14, heads)
+
+	// Published files are only probed once, while the 6 missing ones are probed again on every listing
+	_, err = dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, 20, heads)
+
+	// So a file published after the first listing is mirrored
+	files["/maven2/com/example/sdk/1.0/sdk-1.0.jar"] = "jar 1.0"
+	objects, err = dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Contains(t, objects, "com/example/sdk/1.0/sdk-1.0.jar")
+
+	tmpDir, _ := ioutil.TempDir("", "maven-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("com/example/sdk/1.1/sdk-1.1.jar", path.Join(tmpDir, "sdk-1.1.jar"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "sdk-1.1.jar"))
+	assert.Equal(t, "jar 1.1", string(content))
+
+	// Files are verified against their published .sha1
+	err = dl.GetObject("com/example/sdk/1.1/sdk-1.1-sources.jar", path.Join(tmpDir, "sdk-1.1-sources.jar"))
+	assert.EqualError(t, err, "checksum mismatch for sdk-1.1-sources.jar")
+
+	// And written as is when there is none
+	err = dl.GetObject("com/example/sdk/1.0/sdk-1.0.pom", path.Join(tmpDir, "sdk-1.0.pom"))
+	assert.NoError(t, err)
+
+	assert.True(t, dl.(MutableDownloader).IsMutable("com/example/sdk/maven-metadata.xml.sha1"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("com/example/sdk/1.1/sdk-1.1.jar.sha1"))
+}
+
+func TestMavenMetadataFromListing(t *testing.T) {
+	metadata := `<metadata><versioning><versions><version>1.0</version></versions></versioning></metadata>`
+	files := map[string]string{
+		"/com/example/sdk/maven-metadata.xml": metadata,
+		"/com/example/sdk/1.0/sdk-1.0.pom":    "pom 1.0",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "maven",
+		Config: map[interface{}]interface{}{
+			"maven_url":       server.URL,
+			"maven_artifacts": []interface{}{"com.example:sdk"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.NoError(t, err)
+
+	// A version published after the listing is not referenced by the mirrored metadata
+	files["/com/example/sdk/maven-metadata.xml"] = `<metadata><versioning><versions><version>1.0</version><version>2.0</version></versions></versioning></metadata>`
+	files["/com/example/sdk/maven-metadata.xml.sha1"] = "upstream checksum"
+
+	tmpDir, _ := ioutil.TempDir("", "maven-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("com/example/sdk/maven-metadata.xml", path.Join(tmpDir, "maven-metadata.xml"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "maven-metadata.xml"))
+	assert.Equal(t, metadata, string(content))
+
+	// The checksum is computed from the listed metadata so the two always agree
+	err = dl.GetObject("com/example/sdk/maven-metadata.xml.sha1", path.Join(tmpDir, "maven-metadata.xml.sha1"))
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadFile(path.Join(tmpDir, "maven-metadata.xml.sha1"))
+	assert.Equal(t, testSHA1(metadata), string(content))
+}
+
+func TestMavenInvalidCoordinates(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "maven",
+		Config: map[interface{}]interface{}{
+			"maven_artifacts": []interface{}{"com.example.sdk"},
+		},
+	})
+	assert.EqualError(t, err, "maven_artifacts must be in the form of groupId:artifactId: com.example.sdk")
+}
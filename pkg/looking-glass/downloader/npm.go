@@ -0,0 +1,232 @@
+package downloader
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// defaultNpmURL is the registry used when npm_url is not set
+const defaultNpmURL = "https://registry.npmjs.org/"
+
+// npmAccept asks for the abbreviated packument, which only carries what is needed to install a package
+const npmAccept = "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8"
+
+type npmDownloaderConfig struct {
+	NpmURL      string   `mapstructure:"npm_url"`
+	NpmPackages []string `mapstructure:"npm_packages"`
+	NpmToken    string   `mapstructure:"npm_token"`
+}
+
+// npmPackument is the subset of a package document listing each version's tarball
+type npmPackument struct {
+	Versions map[string]struct {
+		Dist npmDist `json:"dist"`
+	} `json:"versions"`
+}
+
+// npmDist describes a version's tarball and its checksums
+type npmDist struct {
+	Tarball   string `json:"tarball"`
+	Shasum    string `json:"shasum"`
+	Integrity string `json:"integrity"`
+}
+
+type npmDownloader struct {
+	client      *http.Client
+	registryURL *url.URL
+	packages    []string
+	token       string
+	objects     map[string]npmDist
+}
+
+// newNpm returns an initialized npmDownloader struct
+func newNpm(config config.DownloaderConfig) (Downloader, error) {
+	var cfg npmDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateNpmConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rawURL := cfg.NpmURL
+	if rawURL == "" {
+		rawURL = defaultNpmURL
+	}
+	registryURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registries may be hosted beneath a path, so make sure package names resolve beneath it
+	if !strings.HasSuffix(registryURL.Path, "/") {
+		registryURL.Path += "/"
+	}
+
+	downloader := &npmDownloader{
+		client:      http.DefaultClient,
+		registryURL: registryURL,
+		packages:    cfg.NpmPackages,
+		token:       cfg.NpmToken,
+		objects:     map[string]npmDist{},
+	}
+
+	return downloader, nil
+}
+
+// validateNpmConfig validates the the configuration is not missing any required values
+func validateNpmConfig(cfg npmDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"NpmPackages": strings.Join(cfg.NpmPackages, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// newRequest builds a GET request for the URL, only sending the token to the registry's own host
+func (nd *npmDownloader) newRequest(u *url.URL) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if nd.token != "" && u.Host == nd.registryURL.Host {
+		req.Header.Set("Authorization", "Bearer "+nd.token)
+	}
+
+	return req, nil
+}
+
+// getPackument fetches the package document for the package name
+func (nd *npmDownloader) getPackument(name string) (*npmPackument, error) {
+	// Scoped packages keep the @ but have the slash escaped, e.g. @scope%2fname
+	packumentURL := *nd.registryURL
+	packumentURL.Path += name
+	packumentURL.RawPath = nd.registryURL.EscapedPath() + url.PathEscape(name)
+
+	req, err := nd.newRequest(&packumentURL)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", npmAccept)
+
+	body, err := httpGet(nd.client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var packument npmPackument
+	err = json.NewDecoder(body).Decode(&packument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &packument, nil
+}
+
+// buildObjectPath builds the registry style tarball path, e.g. @scope/name/-/name-1.0.0.tgz
+func (nd *npmDownloader) buildObjectPath(name string, version string) string {
+	return fmt.Sprintf("%s/-/%s-%s.tgz", name, path.Base(name), version)
+}
+
+// ListObjects lists the tarball of every version of the configured packages
+func (nd *npmDownloader) ListObjects() ([]string, error) {
+	objects := map[string]npmDist{}
+
+	var objectPaths []string
+	for _, name := range nd.packages {
+		packument, err := nd.getPackument(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for version, manifest := range packument.Versions {
+			if manifest.Dist.Tarball == "" {
+				continue
+			}
+
+			objectPath := nd.buildObjectPath(name, version)
+			objects[objectPath] = manifest.Dist
+			objectPaths = append(objectPaths, objectPath)
+		}
+	}
+	nd.objects = objects
+	sort.Strings(objectPaths)
+
+	return objectPaths, nil
+}
+
+// newNpmHash returns the hash and expected digest for the tarball, preferring the sha512 integrity
+// over the legacy sha1 shasum, or a nil hash if neither is usable
+func newNpmHash(dist npmDist) (hash.Hash, []byte) {
+	for _, integrity := range strings.Fields(dist.Integrity) {
+		if strings.HasPrefix(integrity, "sha512-") {
+			expected, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(integrity, "sha512-"))
+			if err == nil {
+				return sha512.New(), expected
+			}
+		}
+	}
+
+	expected, err := hex.DecodeString(dist.Shasum)
+	if err == nil && len(expected) == sha1.Size {
+		return sha1.New(), expected
+	}
+
+	return nil, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath, verifying its checksum
+func (nd *npmDownloader) GetObject(sourceObj string, targetPath string) error {
+	dist, ok := nd.objects[sourceObj]
+	if !ok {
+		_, err := nd.ListObjects()
+		if err != nil {
+			return err
+		}
+
+		dist, ok = nd.objects[sourceObj]
+		if !ok {
+			return fmt.Errorf("tarball '%s' not found", sourceObj)
+		}
+	}
+
+	tarballURL, err := nd.registryURL.Parse(dist.Tarball)
+	if err != nil {
+		return err
+	}
+
+	req, err := nd.newRequest(tarballURL)
+	if err != nil {
+		return err
+	}
+
+	body, err := httpGet(nd.client, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	h, expected := newNpmHash(dist)
+	if h == nil {
+		return writeObject(body, targetPath)
+	}
+	return writeVerifiedObject(body, targetPath, h, expected)
+}
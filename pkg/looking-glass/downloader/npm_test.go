@@ -0,0 +1,84 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNpmListAndGetObjects(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/npm/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		switch r.URL.EscapedPath() {
+		case "/npm/left-pad":
+			fmt.Fprintf(w, `{"versions": {
+"1.0.0": {"dist": {"tarball": "%[1]s/npm/left-pad/-/left-pad-1.0.0.tgz", "shasum": "7aa8e7ac1a8b2ee5e8b6e2fa3f7b0d3c3d5fd6f4"}},
+"1.1.0": {"dist": {"tarball": "%[1]s/npm/left-pad/-/left-pad-1.1.0.tgz", "integrity": "sha512-z4PhNX7vuL3xVChQ1m2AB9Yg5AULVxXcg/SpIdNs6c5H0NE8XYXysP+DGNKHfuwvY7kxvUdBeoGlODJ6+SfaPg=="}}
+}}`, server.URL)
+		case "/npm/@scope%2Fthing":
+			fmt.Fprintf(w, `{"versions": {"2.0.0": {"dist": {"tarball": "%s/npm/@scope/thing/-/thing-2.0.0.tgz"}}}}`, server.URL)
+		case "/npm/left-pad/-/left-pad-1.1.0.tgz":
+			fmt.Fprint(w, "")
+		case "/npm/@scope/thing/-/thing-2.0.0.tgz":
+			fmt.Fprint(w, "thing")
+		default:
+			fmt.Fprint(w, "corrupt")
+		}
+	})
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "npm",
+		Config: map[interface{}]interface{}{
+			"npm_url":      server.URL + "/npm",
+			"npm_packages": []interface{}{"left-pad", "@scope/thing"},
+			"npm_token":    "secret",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"@scope/thing/-/thing-2.0.0.tgz",
+		"left-pad/-/left-pad-1.0.0.tgz",
+		"left-pad/-/left-pad-1.1.0.tgz",
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "npm-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("@scope/thing/-/thing-2.0.0.tgz", path.Join(tmpDir, "thing-2.0.0.tgz"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "thing-2.0.0.tgz"))
+	assert.Equal(t, "thing", string(content))
+
+	// The empty tarball matches its sha512 integrity, the corrupt one does not match its shasum
+	err = dl.GetObject("left-pad/-/left-pad-1.1.0.tgz", path.Join(tmpDir, "left-pad-1.1.0.tgz"))
+	assert.NoError(t, err)
+	err = dl.GetObject("left-pad/-/left-pad-1.0.0.tgz", path.Join(tmpDir, "left-pad-1.0.0.tgz"))
+	assert.EqualError(t, err, "checksum mismatch for left-pad-1.0.0.tgz")
+	_, err = os.Stat(path.Join(tmpDir, "left-pad-1.0.0.tgz"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNpmMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "npm",
+		Config: map[interface{}]interface{}{
+			"npm_token": "secret",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: NpmPackages")
+}
@@ -0,0 +1,436 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// ociManifestMediaTypes are the manifest formats we accept, covering OCI images, OCI artifacts and Docker images
+var ociManifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.artifact.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ociChallengeParam matches the key="value" pairs of a WWW-Authenticate header
+var ociChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociNextLink matches the URL of a Link header pointing at the next page of tags
+var ociNextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+type ociDownloaderConfig struct {
+	OciRegistry     string   `mapstructure:"oci_registry"`
+	OciRepositories []string `mapstructure:"oci_repositories"`
+	OciTags         []string `mapstructure:"oci_tags"`
+	OciUsername     string   `mapstructure:"oci_username"`
+	OciPassword     string   `mapstructure:"oci_password"`
+}
+
+// ociDescriptor points at a manifest or blob by its digest
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// ociManifest is the union of the fields of an image index and an image or artifact manifest that
+// reference other content
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+	Config    *ociDescriptor  `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Blobs     []ociDescriptor `json:"blobs"`
+}
+
+type ociDownloader struct {
+	client       *http.Client
+	registry     *url.URL
+	repositories []string
+	tags         []string
+	username     string
+	password     string
+	tokens       map[string]string
+	manifests    map[string][]byte
+}
+
+// newOCI returns an initialized ociDownloader struct
+func newOCI(config config.DownloaderConfig) (Downloader, error) {
+	var cfg ociDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateOCIConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registries are usually given as a bare host name, plain HTTP has to be asked for explicitly
+	registry := cfg.OciRegistry
+	if !strings.Contains(registry, "://") {
+		registry = "https://" + registry
+	}
+	registryURL, err := url.Parse(strings.TrimSuffix(registry, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	downloader := &ociDownloader{
+		client:       http.DefaultClient,
+		registry:     registryURL,
+		repositories: cfg.OciRepositories,
+		tags:         cfg.OciTags,
+		username:     cfg.OciUsername,
+		password:     cfg.OciPassword,
+		tokens:       map[string]string{},
+		manifests:    map[string][]byte{},
+	}
+
+	return downloader, nil
+}
+
+// validateOCIConfig validates the the configuration is not missing any required values
+func validateOCIConfig(cfg ociDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"OciRegistry":     cfg.OciRegistry,
+		"OciRepositories": strings.Join(cfg.OciRepositories, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// fetchToken requests a bearer token for the challenge returned by the registry
+func (od *ociDownloader) fetchToken(challenge string) (string, error) {
+	params := map[string]string{}
+	for _, match := range ociChallengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid authentication challenge: %s", challenge)
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if od.username != "" {
+		req.SetBasicAuth(od.username, od.password)
+	}
+
+	body, err := httpGet(od.client, req)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(body).Decode(&tokenResp)
+	if err != nil {
+		return "", err
+	}
+
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// get requests the registry URL for the repository, answering a bearer or basic authentication
+// challenge if the registry responds with one
+func (od *ociDownloader) get(repository string, u string, accept []string) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, mediaType := range accept {
+			req.Header.Add("Accept", mediaType)
+		}
+		if token, ok := od.tokens[repository]; ok {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if od.username != "" {
+			req.SetBasicAuth(od.username, od.password)
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := od.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		resp.Body.Close()
+
+		token, err := od.fetchToken(challenge)
+		if err != nil {
+			return nil, err
+		}
+		od.tokens[repository] = token
+
+		req, err = newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err = od.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned %s", u, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// listTags lists the tags of the repository, following the pagination links
+func (od *ociDownloader) listTags(repository string) ([]string, error) {
+	var tags []string
+
+	next := fmt.Sprintf("%s/v2/%s/tags/list", od.registry, repository)
+	for next != "" {
+		resp, err := od.get(repository, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+
+		next = ""
+		if match := ociNextLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			nextURL, err := od.registry.Parse(match[1])
+			if err != nil {
+				return nil, err
+			}
+			next = nextURL.String()
+		}
+	}
+
+	return tags, nil
+}
+
+// getManifest fetches the manifest for the tag or digest, returning it along with its parsed contents
+func (od *ociDownloader) getManifest(repository string, reference string) ([]byte, *ociManifest, error) {
+	resp, err := od.get(repository, fmt.Sprintf("%s/v2/%s/manifests/%s", od.registry, repository, reference), ociManifestMediaTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var manifest ociManifest
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, &manifest, nil
+}
+
+// listManifestObjects adds the manifest for reference, and everything it references, to objects, keeping
+// the manifest in manifests
+func (od *ociDownloader) listManifestObjects(repository string, reference string, objects map[string]bool, manifests map[string][]byte) error {
+	manifestObject := fmt.Sprintf("%s/manifests/%s", repository, reference)
+	if objects[manifestObject] {
+		return nil
+	}
+	objects[manifestObject] = true
+
+	content, manifest, err := od.getManifest(repository, reference)
+	if err != nil {
+		return err
+	}
+	manifests[manifestObject] = content
+
+	// An index points at a manifest per platform, each of which points at its own blobs
+	for _, child := range manifest.Manifests {
+		err = od.listManifestObjects(repository, child.Digest, objects, manifests)
+		if err != nil {
+			return err
+		}
+	}
+
+	var blobs []ociDescriptor
+	blobs = append(blobs, manifest.Layers...)
+	blobs = append(blobs, manifest.Blobs...)
+	if manifest.Config != nil {
+		blobs = append(blobs, *manifest.Config)
+	}
+	for _, blob := range blobs {
+		objects[fmt.Sprintf("%s/blobs/%s", repository, blob.Digest)] = true
+	}
+
+	return nil
+}
+
+// ListObjects lists the manifests and blobs of every tag in the configured repositories, as
+// repository/manifests/reference and repository/blobs/digest. The manifests are kept from this listing,
+// so a tag moved during a pass is mirrored as it was when its blobs were listed
+func (od *ociDownloader) ListObjects() ([]string, error) {
+	objects := map[string]bool{}
+	manifests := map[string][]byte{}
+
+	for _, repository := range od.repositories {
+		tags := od.tags
+		if len(tags) == 0 {
+			var err error
+			tags, err = od.listTags(repository)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, tag := range tags {
+			err := od.listManifestObjects(repository, tag, objects, manifests)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	od.manifests = manifests
+
+	var objectPaths []string
+	for objectPath := range objects {
+		objectPaths = append(objectPaths, objectPath)
+	}
+	sort.Strings(objectPaths)
+
+	return objectPaths, nil
+}
+
+// IsMutable reports whether the object is a manifest referenced by tag, since tags can be moved to a new
+// manifest and have to be mirrored on every pass, unlike manifests and blobs referenced by digest
+func (od *ociDownloader) IsMutable(sourceObj string) bool {
+	i := strings.LastIndex(sourceObj, "/manifests/")
+	if i <= 0 {
+		return false
+	}
+
+	// Digests are always algorithm:encoded, and tags cannot contain a colon
+	return !strings.Contains(sourceObj[i+len("/manifests/"):], ":")
+}
+
+// parseOCIDigest returns the hash of the digest's algorithm and the expected sum
+func parseOCIDigest(digest string) (hash.Hash, []byte, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid digest '%s'", digest)
+	}
+
+	var h hash.Hash
+	switch parts[0] {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, nil, fmt.Errorf("unsupported digest algorithm '%s'", parts[0])
+	}
+
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil || len(expected) != h.Size() {
+		return nil, nil, fmt.Errorf("invalid digest '%s'", digest)
+	}
+
+	return h, expected, nil
+}
+
+// GetObject downloads the manifest or blob specified in sourceObj to the targetPath, verifying
+// everything referenced by digest against it and writing manifests as they were listed
+func (od *ociDownloader) GetObject(sourceObj string, targetPath string) error {
+	// Repositories can contain slashes, so split on the last manifests or blobs element
+	if i := strings.LastIndex(sourceObj, "/manifests/"); i > 0 {
+		content, ok := od.manifests[sourceObj]
+		if !ok {
+			_, err := od.ListObjects()
+			if err != nil {
+				return err
+			}
+
+			content, ok = od.manifests[sourceObj]
+			if !ok {
+				return fmt.Errorf("manifest '%s' not found", sourceObj)
+			}
+		}
+
+		if od.IsMutable(sourceObj) {
+			return writeObject(bytes.NewReader(content), targetPath)
+		}
+
+		h, expected, err := parseOCIDigest(sourceObj[i+len("/manifests/"):])
+		if err != nil {
+			return err
+		}
+		return writeVerifiedObject(bytes.NewReader(content), targetPath, h, expected)
+	}
+
+	i := strings.LastIndex(sourceObj, "/blobs/")
+	if i <= 0 {
+		return fmt.Errorf("object '%s' is not a manifest or blob", sourceObj)
+	}
+	repository, digest := sourceObj[:i], sourceObj[i+len("/blobs/"):]
+
+	h, expected, err := parseOCIDigest(digest)
+	if err != nil {
+		return err
+	}
+
+	resp, err := od.get(repository, fmt.Sprintf("%s/v2/%s/blobs/%s", od.registry, repository, digest), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return writeVerifiedObject(resp.Body, targetPath, h, expected)
+}
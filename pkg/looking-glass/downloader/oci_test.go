@@ -0,0 +1,208 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// ociTestDigest returns the sha256 digest of the content
+func ociTestDigest(content string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+}
+
+// newTestRegistry fakes a registry that requires a bearer token, serving the responses by path and query,
+// which can be replaced to change the registry's contents
+func newTestRegistry(t *testing.T, responses map[string]string) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "repository:team/tool:pull", r.URL.Query().Get("scope"))
+		fmt.Fprint(w, `{"token":"secret-token"}`)
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="test",scope="repository:team/tool:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == "/v2/team/tool/tags/list" && r.URL.RawQuery == "" {
+			w.Header().Set("Link", `</v2/team/tool/tags/list?last=1.0>; rel="next"`)
+		}
+
+		key := r.URL.Path
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.RawQuery
+		}
+		if resp, ok := responses[key]; ok {
+			fmt.Fprint(w, resp)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	return server
+}
+
+// testRegistryImage returns the responses of a registry holding one multi-platform image tagged 1.0 and
+// latest, along with the digests of its platform manifest, config and layer
+func testRegistryImage() (map[string]string, string, string, string) {
+	layer := "layer contents"
+	config := "config contents"
+	platform := fmt.Sprintf(`{"mediaType":"application/vnd.oci.image.manifest.v1+json",
+		"config":{"digest":"%s"},"layers":[{"digest":"%s"}]}`, ociTestDigest(config), ociTestDigest(layer))
+	index := fmt.Sprintf(`{"mediaType":"application/vnd.oci.image.index.v1+json",
+		"manifests":[{"digest":"%s"}]}`, ociTestDigest(platform))
+
+	responses := map[string]string{
+		"/v2/team/tool/tags/list":                            `{"name":"team/tool","tags":["1.0"]}`,
+		"/v2/team/tool/tags/list?last=1.0":                   `{"name":"team/tool","tags":["latest"]}`,
+		"/v2/team/tool/manifests/1.0":                        index,
+		"/v2/team/tool/manifests/latest":                     index,
+		"/v2/team/tool/manifests/" + ociTestDigest(platform): platform,
+		"/v2/team/tool/blobs/" + ociTestDigest(config):       config,
+		"/v2/team/tool/blobs/" + ociTestDigest(layer):        layer,
+	}
+
+	return responses, ociTestDigest(platform), ociTestDigest(config), ociTestDigest(layer)
+}
+
+func TestOCIListAndGetObjects(t *testing.T) {
+	responses, platformDigest, configDigest, layerDigest := testRegistryImage()
+	server := newTestRegistry(t, responses)
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "oci",
+		Config: map[interface{}]interface{}{
+			"oci_registry":     server.URL,
+			"oci_repositories": []interface{}{"team/tool"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"team/tool/blobs/" + configDigest,
+		"team/tool/blobs/" + layerDigest,
+		"team/tool/manifests/1.0",
+		"team/tool/manifests/latest",
+		"team/tool/manifests/" + platformDigest,
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "oci-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	target := path.Join(tmpDir, "team/tool/blobs/layer")
+	err = dl.GetObject("team/tool/blobs/"+layerDigest, target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "layer contents", string(content))
+}
+
+func TestOCITagManifestFromListing(t *testing.T) {
+	responses, _, _, _ := testRegistryImage()
+	listed := responses["/v2/team/tool/manifests/latest"]
+	server := newTestRegistry(t, responses)
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "oci",
+		Config: map[interface{}]interface{}{
+			"oci_registry":     server.URL,
+			"oci_repositories": []interface{}{"team/tool"},
+			"oci_tags":         []interface{}{"latest"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.NoError(t, err)
+
+	// A tag moved after the listing is mirrored as listed, so it only references blobs that were listed
+	responses["/v2/team/tool/manifests/latest"] = `{"manifests":[{"digest":"sha256:unlisted"}]}`
+
+	tmpDir, _ := ioutil.TempDir("", "oci-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("team/tool/manifests/latest", path.Join(tmpDir, "latest"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "latest"))
+	assert.Equal(t, listed, string(content))
+}
+
+func TestOCIGetObjectVerifiesDigest(t *testing.T) {
+	responses, platformDigest, _, layerDigest := testRegistryImage()
+	responses["/v2/team/tool/blobs/"+layerDigest] = "corrupt"
+	responses["/v2/team/tool/manifests/"+platformDigest] = "{}"
+	server := newTestRegistry(t, responses)
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "oci",
+		Config: map[interface{}]interface{}{
+			"oci_registry":     server.URL,
+			"oci_repositories": []interface{}{"team/tool"},
+		},
+	})
+	assert.NoError(t, err)
+
+	tmpDir, _ := ioutil.TempDir("", "oci-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	// Content not matching the digest in its name is never left behind to be mirrored
+	target := path.Join(tmpDir, "layer")
+	err = dl.GetObject("team/tool/blobs/"+layerDigest, target)
+	assert.EqualError(t, err, "checksum mismatch for layer")
+	_, err = os.Stat(target)
+	assert.True(t, os.IsNotExist(err))
+
+	target = path.Join(tmpDir, "platform")
+	err = dl.GetObject("team/tool/manifests/"+platformDigest, target)
+	assert.EqualError(t, err, "checksum mismatch for platform")
+	_, err = os.Stat(target)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOCIIsMutable(t *testing.T) {
+	dl, err := New(config.DownloaderConfig{
+		Type: "oci",
+		Config: map[interface{}]interface{}{
+			"oci_registry":     "registry.example.com",
+			"oci_repositories": []string{"library/tool"},
+		},
+	})
+	assert.NoError(t, err)
+
+	md, ok := dl.(MutableDownloader)
+	if assert.True(t, ok) {
+		assert.True(t, md.IsMutable("library/tool/manifests/latest"))
+		assert.True(t, md.IsMutable("library/tool/manifests/v1.0"))
+		assert.False(t, md.IsMutable("library/tool/manifests/sha256:0123456789abcdef"))
+		assert.False(t, md.IsMutable("library/tool/blobs/sha256:0123456789abcdef"))
+	}
+}
+
+func TestOCIMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.DownloaderConfig{
+		Type: "oci",
+		Config: map[interface{}]interface{}{
+			"oci_registry": "ghcr.io",
+		},
+	})
+	assert.Equal(t, fmt.Errorf("configuration values cannot be empty: OciRepositories"), err)
+}
@@ -0,0 +1,417 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"golang.org/x/net/html"
+)
+
+// defaultPypiURL is the simple index used when pypi_url is not set
+const defaultPypiURL = "https://pypi.org/simple/"
+
+// pypiRootPageObject is the name of the regenerated simple index root page in the mirror
+const pypiRootPageObject = "simple/index.html"
+
+// pypiAccept asks for the PEP 691 JSON simple API, falling back to the PEP 503 HTML pages
+const pypiAccept = "application/vnd.pypi.simple.v1+json, application/vnd.pypi.simple.v1+html;q=0.2, text/html;q=0.1"
+
+// pypiNameSeparators matches the runs of characters that PEP 503 normalizes to a single dash
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+type pypiDownloaderConfig struct {
+	PypiURL      string   `mapstructure:"pypi_url"`
+	PypiPackages []string `mapstructure:"pypi_packages"`
+	PypiUsername string   `mapstructure:"pypi_username"`
+	PypiPassword string   `mapstructure:"pypi_password"`
+}
+
+// pypiFile is a distribution file listed on a project's simple index page
+type pypiFile struct {
+	Filename       string
+	URL            string
+	Hash           string
+	RequiresPython string
+}
+
+type pypiDownloader struct {
+	client   *http.Client
+	indexURL *url.URL
+	packages []string
+	username string
+	password string
+	objects  map[string]pypiFile
+	files    map[string][]pypiFile
+}
+
+// newPypi returns an initialized pypiDownloader struct
+func newPypi(config config.DownloaderConfig) (Downloader, error) {
+	var cfg pypiDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validatePypiConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rawURL := cfg.PypiURL
+	if rawURL == "" {
+		rawURL = defaultPypiURL
+	}
+	indexURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index is a directory, so make sure project pages resolve beneath it
+	if !strings.HasSuffix(indexURL.Path, "/") {
+		indexURL.Path += "/"
+	}
+
+	var packages []string
+	for _, pkg := range cfg.PypiPackages {
+		packages = append(packages, normalizePypiName(pkg))
+	}
+
+	downloader := &pypiDownloader{
+		client:   http.DefaultClient,
+		indexURL: indexURL,
+		packages: packages,
+		username: cfg.PypiUsername,
+		password: cfg.PypiPassword,
+		objects:  map[string]pypiFile{},
+		files:    map[string][]pypiFile{},
+	}
+
+	return downloader, nil
+}
+
+// validatePypiConfig validates the the configuration is not missing any required values
+func validatePypiConfig(cfg pypiDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"PypiPackages": strings.Join(cfg.PypiPackages, ","),
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// normalizePypiName normalizes a project name as described in PEP 503
+func normalizePypiName(name string) string {
+	return strings.ToLower(pypiNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// get requests the URL, only sending credentials to the index's own host
+func (pd *pypiDownloader) get(u *url.URL, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if pd.username != "" && u.Host == pd.indexURL.Host {
+		req.SetBasicAuth(pd.username, pd.password)
+	}
+
+	resp, err := pd.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned %s", u, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// newPypiFile builds a pypiFile from a link on a project page, splitting the hash fragment off the URL
+func newPypiFile(pageURL *url.URL, filename string, href string, requiresPython string) (pypiFile, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return pypiFile{}, err
+	}
+
+	fileURL := pageURL.ResolveReference(ref)
+	fileHash := fileURL.Fragment
+	fileURL.Fragment = ""
+
+	if filename == "" {
+		filename = path.Base(fileURL.Path)
+	}
+
+	return pypiFile{
+		Filename:       filename,
+		URL:            fileURL.String(),
+		Hash:           fileHash,
+		RequiresPython: requiresPython,
+	}, nil
+}
+
+// parsePypiJSONPage parses a PEP 691 JSON project page
+func parsePypiJSONPage(pageURL *url.URL, r io.Reader) ([]pypiFile, error) {
+	var page struct {
+		Files []struct {
+			Filename       string            `json:"filename"`
+			URL            string            `json:"url"`
+			Hashes         map[string]string `json:"hashes"`
+			RequiresPython string            `json:"requires-python"`
+		} `json:"files"`
+	}
+	err := json.NewDecoder(r).Decode(&page)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []pypiFile
+	for _, f := range page.Files {
+		file, err := newPypiFile(pageURL, f.Filename, f.URL, f.RequiresPython)
+		if err != nil {
+			return nil, err
+		}
+
+		// The JSON API lists hashes separately, carry the strongest one as a URL style fragment
+		if digest, ok := f.Hashes["sha256"]; ok {
+			file.Hash = "sha256=" + digest
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// parsePypiHTMLPage parses a PEP 503 HTML project page
+func parsePypiHTMLPage(pageURL *url.URL, r io.Reader) ([]pypiFile, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []pypiFile
+	var walkErr error
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			var href, requiresPython string
+			for _, attr := range n.Attr {
+				switch attr.Key {
+				case "href":
+					href = attr.Val
+				case "data-requires-python":
+					requiresPython = attr.Val
+				}
+			}
+
+			var filename string
+			if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+				filename = strings.TrimSpace(n.FirstChild.Data)
+			}
+
+			if href != "" {
+				file, err := newPypiFile(pageURL, filename, href, requiresPython)
+				if err != nil {
+					walkErr = err
+					return
+				}
+				files = append(files, file)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return files, walkErr
+}
+
+// getPackageFiles lists the distribution files of the package from the simple index
+func (pd *pypiDownloader) getPackageFiles(pkg string) ([]pypiFile, error) {
+	pageURL := pd.indexURL.ResolveReference(&url.URL{Path: pkg + "/"})
+
+	resp, err := pd.get(pageURL, pypiAccept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return parsePypiJSONPage(pageURL, resp.Body)
+	}
+	return parsePypiHTMLPage(pageURL, resp.Body)
+}
+
+// pypiFileObjectPath returns the object path a distribution file is mirrored to
+func pypiFileObjectPath(pkg string, filename string) string {
+	return fmt.Sprintf("packages/%s/%s", pkg, filename)
+}
+
+// pypiProjectPageObjectPath returns the object path of the regenerated simple index page for the package
+func pypiProjectPageObjectPath(pkg string) string {
+	return fmt.Sprintf("simple/%s/index.html", pkg)
+}
+
+// ListObjects lists every distribution file of the configured packages, followed by the regenerated
+// simple index pages
+func (pd *pypiDownloader) ListObjects() ([]string, error) {
+	objects := map[string]pypiFile{}
+	packageFiles := map[string][]pypiFile{}
+
+	var objectPaths []string
+	for _, pkg := range pd.packages {
+		files, err := pd.getPackageFiles(pkg)
+		if err != nil {
+			return nil, err
+		}
+		packageFiles[pkg] = files
+
+		for _, file := range files {
+			objectPath := pypiFileObjectPath(pkg, file.Filename)
+			objects[objectPath] = file
+			objectPaths = append(objectPaths, objectPath)
+		}
+	}
+	pd.objects = objects
+	pd.files = packageFiles
+	sort.Strings(objectPaths)
+
+	// The index pages are built from the files listed here, so they go after them
+	for _, pkg := range pd.packages {
+		objectPaths = append(objectPaths, pypiProjectPageObjectPath(pkg))
+	}
+	objectPaths = append(objectPaths, pypiRootPageObject)
+
+	return objectPaths, nil
+}
+
+// IsMutable reports whether the object is one of the simple index pages, which have to be mirrored on
+// every pass
+func (pd *pypiDownloader) IsMutable(sourceObj string) bool {
+	return strings.HasPrefix(sourceObj, "simple/")
+}
+
+// buildRootPage renders the simple index page listing the mirrored packages
+func (pd *pypiDownloader) buildRootPage() []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("<!DOCTYPE html>\n<html><body>\n")
+	for _, pkg := range pd.packages {
+		fmt.Fprintf(&buf, "<a href=\"%s/\">%s</a>\n", html.EscapeString(pkg), html.EscapeString(pkg))
+	}
+	buf.WriteString("</body></html>\n")
+
+	return buf.Bytes()
+}
+
+// buildProjectPage renders the simple index page for the package from the last listing, linking to the
+// mirrored files with their hash fragments preserved
+func (pd *pypiDownloader) buildProjectPage(pkg string) ([]byte, error) {
+	files, ok := pd.files[pkg]
+	if !ok {
+		_, err := pd.ListObjects()
+		if err != nil {
+			return nil, err
+		}
+		files = pd.files[pkg]
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html><body>\n")
+	for _, file := range files {
+		href := "../../" + pypiFileObjectPath(pkg, url.PathEscape(file.Filename))
+		if file.Hash != "" {
+			href += "#" + file.Hash
+		}
+
+		buf.WriteString("<a href=\"" + html.EscapeString(href) + "\"")
+		if file.RequiresPython != "" {
+			buf.WriteString(" data-requires-python=\"" + html.EscapeString(file.RequiresPython) + "\"")
+		}
+		buf.WriteString(">" + html.EscapeString(file.Filename) + "</a>\n")
+	}
+	buf.WriteString("</body></html>\n")
+
+	return buf.Bytes(), nil
+}
+
+// newPypiHash returns the hash and expected digest matching the fragment, or a nil hash if it is not
+// a supported algorithm
+func newPypiHash(fragment string) (hash.Hash, []byte) {
+	parts := strings.SplitN(fragment, "=", 2)
+	if len(parts) != 2 || parts[0] != "sha256" {
+		return nil, nil
+	}
+
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil
+	}
+	return sha256.New(), expected
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath, verifying its sha256 hash
+// when the index provides one
+func (pd *pypiDownloader) GetObject(sourceObj string, targetPath string) error {
+	if sourceObj == pypiRootPageObject {
+		return writeObject(bytes.NewReader(pd.buildRootPage()), targetPath)
+	}
+
+	for _, pkg := range pd.packages {
+		if sourceObj == pypiProjectPageObjectPath(pkg) {
+			content, err := pd.buildProjectPage(pkg)
+			if err != nil {
+				return err
+			}
+			return writeObject(bytes.NewReader(content), targetPath)
+		}
+	}
+
+	file, ok := pd.objects[sourceObj]
+	if !ok {
+		_, err := pd.ListObjects()
+		if err != nil {
+			return err
+		}
+
+		file, ok = pd.objects[sourceObj]
+		if !ok {
+			return fmt.Errorf("file '%s' not found", sourceObj)
+		}
+	}
+
+	fileURL, err := url.Parse(file.URL)
+	if err != nil {
+		return err
+	}
+
+	resp, err := pd.get(fileURL, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	h, expected := newPypiHash(file.Hash)
+	if h == nil {
+		return writeObject(resp.Body, targetPath)
+	}
+	return writeVerifiedObject(resp.Body, targetPath, h, expected)
+}
@@ -0,0 +1,124 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSimpleIndex serves a simple index under /simple/ with an HTML page for one project and a JSON
+// page for another
+func newTestSimpleIndex() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/simple/my-package/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<!DOCTYPE html><html><body>
+<a href="../../files/my_package-1.0.tar.gz#sha256=e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855">my_package-1.0.tar.gz</a>
+<a href="../../files/my_package-1.0-py3-none-any.whl#sha256=00ff" data-requires-python="&gt;=3.6">my_package-1.0-py3-none-any.whl</a>
+</body></html>`)
+	})
+	mux.HandleFunc("/simple/other/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+		fmt.Fprintf(w, `{"meta": {"api-version": "1.0"}, "name": "other", "files": [
+{"filename": "other-2.0.tar.gz", "url": "%s/files/other-2.0.tar.gz", "hashes": {"sha256": "abcd"}}
+]}`, server.URL)
+	})
+	mux.HandleFunc("/files/my_package-1.0.tar.gz", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/files/my_package-1.0-py3-none-any.whl", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "wheel")
+	})
+
+	return server
+}
+
+func TestPypiListAndGetObjects(t *testing.T) {
+	server := newTestSimpleIndex()
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "pypi",
+		Config: map[interface{}]interface{}{
+			"pypi_url":      server.URL + "/simple",
+			"pypi_packages": []interface{}{"My_Package", "other"},
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"packages/my-package/my_package-1.0-py3-none-any.whl",
+		"packages/my-package/my_package-1.0.tar.gz",
+		"packages/other/other-2.0.tar.gz",
+		"simple/my-package/index.html",
+		"simple/other/index.html",
+		"simple/index.html",
+	}, objects)
+
+	tmpDir, _ := ioutil.TempDir("", "pypi-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	// The empty sdist matches its hash, the wheel does not
+	err = dl.GetObject("packages/my-package/my_package-1.0.tar.gz", path.Join(tmpDir, "sdist"))
+	assert.NoError(t, err)
+	err = dl.GetObject("packages/my-package/my_package-1.0-py3-none-any.whl", path.Join(tmpDir, "wheel"))
+	assert.EqualError(t, err, "checksum mismatch for wheel")
+
+	err = dl.GetObject("simple/my-package/index.html", path.Join(tmpDir, "my-package.html"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "my-package.html"))
+	assert.Contains(t, string(content), `<a href="../../packages/my-package/my_package-1.0.tar.gz#sha256=e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855">my_package-1.0.tar.gz</a>`)
+	assert.Contains(t, string(content), `data-requires-python="&gt;=3.6"`)
+
+	err = dl.GetObject("simple/other/index.html", path.Join(tmpDir, "other.html"))
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadFile(path.Join(tmpDir, "other.html"))
+	assert.Contains(t, string(content), `<a href="../../packages/other/other-2.0.tar.gz#sha256=abcd">other-2.0.tar.gz</a>`)
+
+	assert.True(t, dl.(MutableDownloader).IsMutable("simple/index.html"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("packages/other/other-2.0.tar.gz"))
+}
+
+func TestPypiProjectPageFromListing(t *testing.T) {
+	files := `<a href="/files/tool-1.0.tar.gz">tool-1.0.tar.gz</a>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<!DOCTYPE html><html><body>%s</body></html>", files)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "pypi",
+		Config: map[interface{}]interface{}{
+			"pypi_url":      server.URL,
+			"pypi_packages": []interface{}{"tool"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = dl.ListObjects()
+	assert.NoError(t, err)
+
+	// A file published upstream during the pass must not appear on the mirrored page
+	files += `<a href="/files/tool-1.1.tar.gz">tool-1.1.tar.gz</a>`
+
+	tmpDir, _ := ioutil.TempDir("", "pypi-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("simple/tool/index.html", path.Join(tmpDir, "tool.html"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "tool.html"))
+	assert.Contains(t, string(content), "tool-1.0.tar.gz")
+	assert.NotContains(t, string(content), "tool-1.1.tar.gz")
+}
+
+func TestNormalizePypiName(t *testing.T) {
+	assert.Equal(t, "friendly-bard", normalizePypiName("Friendly._-Bard"))
+}
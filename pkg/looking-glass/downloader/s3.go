@@ -1,17 +1,15 @@
 package downloader
 
 import (
-	"fmt"
 	"os"
 	"path"
-	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	sss "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/awsutil"
 	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
 )
 
@@ -41,7 +39,7 @@ func newS3(config config.DownloaderConfig) (Downloader, error) {
 		return nil, err
 	}
 
-	awsSess, err := createAwsSession(cfg.AwsKey, cfg.AwsSecret, cfg.AwsRegion)
+	awsSess, err := awsutil.NewSession(cfg.AwsKey, cfg.AwsSecret, cfg.AwsRegion, "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -63,30 +61,7 @@ func validateS3Config(cfg s3Config) error {
 		"AwsBucket": cfg.AwsBucket,
 	}
 
-	var missingConfigs []string
-
-	// Check for configs that are not set
-	for cfgName, cfgValue := range requiredConfigs {
-		if cfgValue == "" {
-			missingConfigs = append(missingConfigs, cfgName)
-		}
-	}
-
-	// Error on all the missing configs
-	if len(missingConfigs) > 0 {
-		return fmt.Errorf("configuration values cannot be empty: %s", strings.Join(missingConfigs, ", "))
-	}
-
-	return nil
-}
-
-func createAwsSession(awsKey string, awsSecret string, awsRegion string) (*session.Session, error) {
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(awsRegion),
-		Credentials: credentials.NewStaticCredentials(awsKey, awsSecret, ""),
-	})
-
-	return sess, err
+	return config.CheckRequired(requiredConfigs)
 }
 
 // ListObjects lists the objects available in the S3 bucket
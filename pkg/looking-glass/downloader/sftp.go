@@ -0,0 +1,223 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/sftp"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpDialTimeout is how long to wait when connecting to the SSH server
+const sftpDialTimeout = 30 * time.Second
+
+type sftpDownloaderConfig struct {
+	SftpHost                  string `mapstructure:"sftp_host"`
+	SftpUser                  string `mapstructure:"sftp_user"`
+	SftpPassword              string `mapstructure:"sftp_password"`
+	SftpPrivateKey            string `mapstructure:"sftp_private_key"`
+	SftpPrivateKeyPassphrase  string `mapstructure:"sftp_private_key_passphrase"`
+	SftpKnownHosts            string `mapstructure:"sftp_known_hosts"`
+	SftpInsecureIgnoreHostKey bool   `mapstructure:"sftp_insecure_ignore_host_key"`
+	SftpPrefix                string `mapstructure:"sftp_prefix"`
+}
+
+type sftpDownloader struct {
+	address   string
+	sshConfig *ssh.ClientConfig
+	prefix    string
+}
+
+// newSFTP returns an initialized sftpDownloader struct
+func newSFTP(config config.DownloaderConfig) (Downloader, error) {
+	var cfg sftpDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSFTPConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sshConfig, err := createSSHClientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Default to the standard SSH port when only a host name is given
+	address := cfg.SftpHost
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+
+	// Relative prefixes are resolved against the user's home directory by the server
+	prefix := "."
+	if cfg.SftpPrefix != "" {
+		prefix = path.Clean(cfg.SftpPrefix)
+	}
+
+	downloader := &sftpDownloader{
+		address:   address,
+		sshConfig: sshConfig,
+		prefix:    prefix,
+	}
+
+	return downloader, nil
+}
+
+// validateSFTPConfig validates the the configuration is not missing any required values
+func validateSFTPConfig(cfg sftpDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"SftpHost": cfg.SftpHost,
+		"SftpUser": cfg.SftpUser,
+	}
+
+	err := config.CheckRequired(requiredConfigs)
+	if err != nil {
+		return err
+	}
+
+	if cfg.SftpPassword == "" && cfg.SftpPrivateKey == "" {
+		return fmt.Errorf("one of sftp_password or sftp_private_key must be set")
+	}
+
+	if cfg.SftpKnownHosts == "" && !cfg.SftpInsecureIgnoreHostKey {
+		return fmt.Errorf("sftp_known_hosts must be set unless sftp_insecure_ignore_host_key is true")
+	}
+
+	return nil
+}
+
+// createSSHClientConfig builds the authentication and host key verification for the SSH connection
+func createSSHClientConfig(cfg sftpDownloaderConfig) (*ssh.ClientConfig, error) {
+	var authMethods []ssh.AuthMethod
+
+	if cfg.SftpPrivateKey != "" {
+		keyBytes, err := ioutil.ReadFile(cfg.SftpPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		var signer ssh.Signer
+		if cfg.SftpPrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(cfg.SftpPrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(keyBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key %s: %v", cfg.SftpPrivateKey, err)
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	if cfg.SftpPassword != "" {
+		authMethods = append(authMethods, ssh.Password(cfg.SftpPassword))
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if cfg.SftpKnownHosts != "" {
+		var err error
+		hostKeyCallback, err = knownhosts.New(cfg.SftpKnownHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:            cfg.SftpUser,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sftpDialTimeout,
+	}
+
+	return sshConfig, nil
+}
+
+// connect opens an SSH connection and starts an SFTP session on it, the caller is responsible for
+// closing both
+func (sd *sftpDownloader) connect() (*ssh.Client, *sftp.Client, error) {
+	sshClient, err := ssh.Dial("tcp", sd.address, sd.sshConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, nil, err
+	}
+
+	return sshClient, sftpClient, nil
+}
+
+// remotePath returns the path on the server for an object returned by ListObjects
+func (sd *sftpDownloader) remotePath(sourceObj string) string {
+	if strings.HasPrefix(sd.prefix, "/") {
+		return "/" + sourceObj
+	}
+	return sourceObj
+}
+
+// ListObjects lists the files available beneath the SFTP prefix
+func (sd *sftpDownloader) ListObjects() ([]string, error) {
+	sshClient, sftpClient, err := sd.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer sshClient.Close()
+	defer sftpClient.Close()
+
+	return sd.listObjects(sftpClient)
+}
+
+// listObjects walks the prefix with an established SFTP session
+func (sd *sftpDownloader) listObjects(sftpClient *sftp.Client) ([]string, error) {
+	var objects []string
+
+	walker := sftpClient.Walk(sd.prefix)
+	for walker.Step() {
+		err := walker.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		if walker.Stat().Mode().IsRegular() {
+			objects = append(objects, strings.TrimPrefix(walker.Path(), "/"))
+		}
+	}
+
+	return objects, nil
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath
+func (sd *sftpDownloader) GetObject(sourceObj string, targetPath string) error {
+	sshClient, sftpClient, err := sd.connect()
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+	defer sftpClient.Close()
+
+	return sd.getObject(sftpClient, sourceObj, targetPath)
+}
+
+// getObject downloads the object with an established SFTP session
+func (sd *sftpDownloader) getObject(sftpClient *sftp.Client, sourceObj string, targetPath string) error {
+	f, err := sftpClient.Open(sd.remotePath(sourceObj))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeObject(f, targetPath)
+}
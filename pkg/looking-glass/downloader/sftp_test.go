@@ -0,0 +1,120 @@
+package downloader
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSFTPClient starts an SFTP server on the local filesystem and returns a client connected to it
+// over in-memory pipes, bypassing SSH, along with a function shutting both down
+func newTestSFTPClient(t *testing.T) (*sftp.Client, func()) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter})
+	assert.NoError(t, err)
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	assert.NoError(t, err)
+
+	// The client waits for the server to hang up when closing
+	return client, func() {
+		server.Close()
+		client.Close()
+	}
+}
+
+func TestSFTPListAndGetObjects(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "sftp-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "sftp-dst")
+	defer os.RemoveAll(dstDir)
+
+	os.MkdirAll(path.Join(srcDir, "pub/v1.0"), os.ModePerm)
+	os.MkdirAll(path.Join(srcDir, "pub/empty"), os.ModePerm)
+	ioutil.WriteFile(path.Join(srcDir, "pub/README"), []byte("readme"), 0644)
+	ioutil.WriteFile(path.Join(srcDir, "pub/v1.0/tool.tar.gz"), []byte("tarball"), 0644)
+	ioutil.WriteFile(path.Join(srcDir, "outside.txt"), []byte("outside"), 0644)
+
+	client, closeClient := newTestSFTPClient(t)
+	defer closeClient()
+
+	sd := &sftpDownloader{prefix: path.Join(srcDir, "pub")}
+
+	objects, err := sd.listObjects(client)
+	assert.NoError(t, err)
+
+	// Absolute prefixes are listed without the leading slash
+	srcObj := strings.TrimPrefix(srcDir, "/")
+	assert.ElementsMatch(t, []string{srcObj + "/pub/README", srcObj + "/pub/v1.0/tool.tar.gz"}, objects)
+
+	target := path.Join(dstDir, "tool.tar.gz")
+	err = sd.getObject(client, srcObj+"/pub/v1.0/tool.tar.gz", target)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "tarball", string(content))
+}
+
+func TestSFTPRemotePath(t *testing.T) {
+	absolute := &sftpDownloader{prefix: "/srv/pub"}
+	assert.Equal(t, "/srv/pub/file.txt", absolute.remotePath("srv/pub/file.txt"))
+
+	relative := &sftpDownloader{prefix: "pub"}
+	assert.Equal(t, "pub/file.txt", relative.remotePath("pub/file.txt"))
+}
+
+func TestSFTPDefaults(t *testing.T) {
+	dl, err := New(config.DownloaderConfig{
+		Type: "sftp",
+		Config: map[interface{}]interface{}{
+			"sftp_host":                     "sftp.example.com",
+			"sftp_user":                     "mirror",
+			"sftp_password":                 "secret",
+			"sftp_insecure_ignore_host_key": true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "sftp.example.com:22", dl.(*sftpDownloader).address)
+	assert.Equal(t, ".", dl.(*sftpDownloader).prefix)
+}
+
+func TestSFTPValidateConfig(t *testing.T) {
+	err := validateSFTPConfig(sftpDownloaderConfig{SftpPassword: "secret"})
+	assert.EqualError(t, err, "configuration values cannot be empty: SftpHost, SftpUser")
+
+	err = validateSFTPConfig(sftpDownloaderConfig{
+		SftpHost:                  "sftp.example.com",
+		SftpUser:                  "mirror",
+		SftpInsecureIgnoreHostKey: true,
+	})
+	assert.EqualError(t, err, "one of sftp_password or sftp_private_key must be set")
+
+	err = validateSFTPConfig(sftpDownloaderConfig{
+		SftpHost:       "sftp.example.com",
+		SftpUser:       "mirror",
+		SftpPrivateKey: "/home/mirror/.ssh/id_rsa",
+	})
+	assert.EqualError(t, err, "sftp_known_hosts must be set unless sftp_insecure_ignore_host_key is true")
+
+	err = validateSFTPConfig(sftpDownloaderConfig{
+		SftpHost:       "sftp.example.com",
+		SftpUser:       "mirror",
+		SftpPassword:   "secret",
+		SftpKnownHosts: "/home/mirror/.ssh/known_hosts",
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,346 @@
+package downloader
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/ulikunitz/xz"
+)
+
+// yumRepomdFile is the path of the file describing a repository's metadata
+const yumRepomdFile = "repodata/repomd.xml"
+
+// yumSignatureFiles are the optional detached signature and public key published next to repomd.xml
+var yumSignatureFiles = []string{yumRepomdFile + ".asc", yumRepomdFile + ".key"}
+
+type yumDownloaderConfig struct {
+	YumURL      string `mapstructure:"yum_url"`
+	YumUsername string `mapstructure:"yum_username"`
+	YumPassword string `mapstructure:"yum_password"`
+}
+
+// yumChecksum is a checksum element of repomd.xml or primary.xml
+type yumChecksum struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// yumLocation is a location element of repomd.xml or primary.xml
+type yumLocation struct {
+	Href string `xml:"href,attr"`
+}
+
+// yumRepomd is the subset of repomd.xml listing the repository's metadata files
+type yumRepomd struct {
+	Data []struct {
+		Type     string      `xml:"type,attr"`
+		Checksum yumChecksum `xml:"checksum"`
+		Location yumLocation `xml:"location"`
+	} `xml:"data"`
+}
+
+// yumPackage is the subset of a package element of primary.xml needed to mirror it
+type yumPackage struct {
+	Checksum yumChecksum `xml:"checksum"`
+	Location yumLocation `xml:"location"`
+}
+
+type yumDownloader struct {
+	client    *http.Client
+	repoURL   *url.URL
+	username  string
+	password  string
+	checksums map[string]yumChecksum
+	repomd    map[string][]byte
+}
+
+// newYum returns an initialized yumDownloader struct
+func newYum(config config.DownloaderConfig) (Downloader, error) {
+	var cfg yumDownloaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateYumConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repoURL, err := url.Parse(cfg.YumURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repo URL is a directory, so make sure repodata and packages resolve beneath it
+	if !strings.HasSuffix(repoURL.Path, "/") {
+		repoURL.Path += "/"
+	}
+
+	downloader := &yumDownloader{
+		client:    http.DefaultClient,
+		repoURL:   repoURL,
+		username:  cfg.YumUsername,
+		password:  cfg.YumPassword,
+		checksums: map[string]yumChecksum{},
+		repomd:    map[string][]byte{},
+	}
+
+	return downloader, nil
+}
+
+// validateYumConfig validates the the configuration is not missing any required values
+func validateYumConfig(cfg yumDownloaderConfig) error {
+	requiredConfigs := map[string]string{
+		"YumURL": cfg.YumURL,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// request sends a request for the path relative to the repo URL
+func (yd *yumDownloader) request(method string, objectPath string) (*http.Response, error) {
+	req, err := http.NewRequest(method, yd.repoURL.ResolveReference(&url.URL{Path: objectPath}).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if yd.username != "" {
+		req.SetBasicAuth(yd.username, yd.password)
+	}
+
+	return yd.client.Do(req)
+}
+
+// getBody requests the path relative to the repo URL, failing on any non successful status
+func (yd *yumDownloader) getBody(objectPath string) (io.ReadCloser, error) {
+	resp, err := yd.request(http.MethodGet, objectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned %s", resp.Request.URL, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// getFile fetches the path relative to the repo URL, reporting false if the optional file is not published
+func (yd *yumDownloader) getFile(objectPath string) ([]byte, bool, error) {
+	resp, err := yd.request(http.MethodGet, objectPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, false, fmt.Errorf("GET %s returned %s", resp.Request.URL, resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return content, true, nil
+}
+
+// getPackages fetches primary.xml and parses its package elements one at a time, since it lists every
+// package in the repository
+func (yd *yumDownloader) getPackages(primaryPath string) ([]yumPackage, error) {
+	body, err := yd.getBody(primaryPath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var r io.Reader = body
+	switch path.Ext(primaryPath) {
+	case ".gz":
+		r, err = gzip.NewReader(body)
+	case ".xz":
+		r, err = xz.NewReader(body)
+	case ".bz2":
+		r = bzip2.NewReader(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []yumPackage
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "package" {
+			var pkg yumPackage
+			err = decoder.DecodeElement(&pkg, &start)
+			if err != nil {
+				return nil, err
+			}
+			packages = append(packages, pkg)
+		}
+	}
+
+	return packages, nil
+}
+
+// ListObjects lists every package in the repository's primary.xml, followed by the repodata files and
+// repomd.xml. The repomd.xml and its signature are fetched while listing and kept, so the mirrored
+// repomd.xml always matches the repodata files verified against it
+func (yd *yumDownloader) ListObjects() ([]string, error) {
+	checksums := map[string]yumChecksum{}
+	repomdFiles := map[string][]byte{}
+
+	content, ok, err := yd.getFile(yumRepomdFile)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no %s in the repository", yumRepomdFile)
+	}
+	repomdFiles[yumRepomdFile] = content
+
+	// The signature is fetched straight after repomd.xml, before the repodata which can take a while, so it
+	// signs the repomd.xml that is kept
+	var signatures []string
+	for _, file := range yumSignatureFiles {
+		content, ok, err := yd.getFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			repomdFiles[file] = content
+			signatures = append(signatures, file)
+		}
+	}
+
+	var repomd yumRepomd
+	err = xml.Unmarshal(content, &repomd)
+	if err != nil {
+		return nil, err
+	}
+
+	var repodata []string
+	for _, data := range repomd.Data {
+		repodata = append(repodata, data.Location.Href)
+		checksums[data.Location.Href] = data.Checksum
+
+		if data.Type != "primary" {
+			continue
+		}
+
+		packages, err := yd.getPackages(data.Location.Href)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range packages {
+			checksums[pkg.Location.Href] = pkg.Checksum
+		}
+	}
+	yd.checksums = checksums
+	sort.Strings(repodata)
+
+	var objects []string
+	for objectPath := range checksums {
+		if !strings.HasPrefix(objectPath, "repodata/") {
+			objects = append(objects, objectPath)
+		}
+	}
+	sort.Strings(objects)
+
+	// The repodata and repomd.xml go after the packages they reference
+	objects = append(objects, repodata...)
+	objects = append(objects, signatures...)
+	yd.repomd = repomdFiles
+
+	return append(objects, yumRepomdFile), nil
+}
+
+// IsMutable reports whether the object is repomd.xml, its signature, or a repodata file whose name does
+// not include its checksum, all of which have to be mirrored on every pass
+func (yd *yumDownloader) IsMutable(sourceObj string) bool {
+	if !strings.HasPrefix(sourceObj, "repodata/") {
+		return false
+	}
+
+	checksum, ok := yd.checksums[sourceObj]
+	return !ok || checksum.Value == "" || !strings.Contains(path.Base(sourceObj), checksum.Value)
+}
+
+// newYumHash returns the hash for the checksum type, or nil if it is not supported
+func newYumHash(checksumType string) hash.Hash {
+	switch checksumType {
+	case "sha", "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	default:
+		return nil
+	}
+}
+
+// GetObject downloads the object specified in sourceObj to the targetPath, verifying it against the
+// checksum from the metadata and writing repomd.xml and its signature as they were listed
+func (yd *yumDownloader) GetObject(sourceObj string, targetPath string) error {
+	if strings.HasPrefix(sourceObj, yumRepomdFile) {
+		content, ok := yd.repomd[sourceObj]
+		if !ok {
+			_, err := yd.ListObjects()
+			if err != nil {
+				return err
+			}
+
+			content, ok = yd.repomd[sourceObj]
+			if !ok {
+				return fmt.Errorf("'%s' not found in the listed repository", sourceObj)
+			}
+		}
+
+		return writeObject(bytes.NewReader(content), targetPath)
+	}
+
+	body, err := yd.getBody(sourceObj)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	checksum := yd.checksums[sourceObj]
+	h := newYumHash(checksum.Type)
+	expected, err := hex.DecodeString(strings.TrimSpace(checksum.Value))
+	if h == nil || err != nil || len(expected) == 0 {
+		return writeObject(body, targetPath)
+	}
+	return writeVerifiedObject(body, targetPath, h, expected)
+}
@@ -0,0 +1,117 @@
+package downloader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYumListAndGetObjects(t *testing.T) {
+	var primary bytes.Buffer
+	gz := gzip.NewWriter(&primary)
+	fmt.Fprint(gz, `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" packages="2">
+<package type="rpm">
+  <name>agent</name>
+  <checksum type="sha256" pkgid="YES">e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855</checksum>
+  <location href="Packages/agent-1.0-1.x86_64.rpm"/>
+</package>
+<package type="rpm">
+  <name>corrupt</name>
+  <checksum type="sha256" pkgid="YES">e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855</checksum>
+  <location href="Packages/corrupt-1.0-1.noarch.rpm"/>
+</package>
+</metadata>`)
+	gz.Close()
+	primarySum := sha256.Sum256(primary.Bytes())
+	primaryHex := hex.EncodeToString(primarySum[:])
+
+	files := map[string]string{
+		"/repo/repodata/repomd.xml": fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo">
+  <data type="primary">
+    <checksum type="sha256">%[1]s</checksum>
+    <location href="repodata/%[1]s-primary.xml.gz"/>
+  </data>
+  <data type="filelists">
+    <checksum type="sha256">abcd</checksum>
+    <location href="repodata/filelists.xml.gz"/>
+  </data>
+</repomd>`, primaryHex),
+		"/repo/repodata/repomd.xml.asc":                    "signature",
+		"/repo/repodata/" + primaryHex + "-primary.xml.gz": primary.String(),
+		"/repo/Packages/agent-1.0-1.x86_64.rpm":            "",
+		"/repo/Packages/corrupt-1.0-1.noarch.rpm":          "corrupt",
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	dl, err := New(config.DownloaderConfig{
+		Type: "yum",
+		Config: map[interface{}]interface{}{
+			"yum_url": server.URL + "/repo",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := dl.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Packages/agent-1.0-1.x86_64.rpm",
+		"Packages/corrupt-1.0-1.noarch.rpm",
+		"repodata/" + primaryHex + "-primary.xml.gz",
+		"repodata/filelists.xml.gz",
+		"repodata/repomd.xml.asc",
+		"repodata/repomd.xml",
+	}, objects)
+
+	// The signature is fetched along with repomd.xml, before any repodata
+	assert.Equal(t, []string{
+		"/repo/repodata/repomd.xml",
+		"/repo/repodata/repomd.xml.asc",
+		"/repo/repodata/repomd.xml.key",
+		"/repo/repodata/" + primaryHex + "-primary.xml.gz",
+	}, requests)
+
+	tmpDir, _ := ioutil.TempDir("", "yum-downloader")
+	defer os.RemoveAll(tmpDir)
+
+	err = dl.GetObject("Packages/agent-1.0-1.x86_64.rpm", path.Join(tmpDir, "agent.rpm"))
+	assert.NoError(t, err)
+	err = dl.GetObject("Packages/corrupt-1.0-1.noarch.rpm", path.Join(tmpDir, "corrupt.rpm"))
+	assert.EqualError(t, err, "checksum mismatch for corrupt.rpm")
+	err = dl.GetObject("repodata/"+primaryHex+"-primary.xml.gz", path.Join(tmpDir, "primary.xml.gz"))
+	assert.NoError(t, err)
+
+	// repomd.xml and its signature are served as they were listed, even once the repository has changed
+	files["/repo/repodata/repomd.xml.asc"] = "new signature"
+	err = dl.GetObject("repodata/repomd.xml.asc", path.Join(tmpDir, "repomd.xml.asc"))
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(path.Join(tmpDir, "repomd.xml.asc"))
+	assert.Equal(t, "signature", string(content))
+
+	assert.True(t, dl.(MutableDownloader).IsMutable("repodata/repomd.xml"))
+	assert.True(t, dl.(MutableDownloader).IsMutable("repodata/filelists.xml.gz"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("repodata/"+primaryHex+"-primary.xml.gz"))
+	assert.False(t, dl.(MutableDownloader).IsMutable("Packages/agent-1.0-1.x86_64.rpm"))
+}
@@ -0,0 +1,121 @@
+package uploader
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/jfrog/jfrog-client-go/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/artifactoryutil"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+type artifactoryUploaderConfig struct {
+	ArtifactoryURL      string `mapstructure:"artifactory_url"`
+	ArtifactoryUserName string `mapstructure:"artifactory_username"`
+	ArtifactoryKey      string `mapstructure:"artifactory_key"`
+	ArtifactoryRepo     string `mapstructure:"artifactory_repo"`
+}
+
+type artifactoryUploader struct {
+	artifactoryManager *artifactory.ArtifactoryServicesManager
+	repo               string
+}
+
+// newArtifactory returns an initialized artifactoryUploader struct
+func newArtifactory(config config.UploaderConfig) (Uploader, error) {
+	var cfg artifactoryUploaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateArtifactoryConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	artMgr, err := artifactoryutil.NewManager(cfg.ArtifactoryURL, cfg.ArtifactoryKey, cfg.ArtifactoryUserName)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := &artifactoryUploader{
+		artifactoryManager: artMgr,
+		repo:               cfg.ArtifactoryRepo,
+	}
+
+	return uploader, nil
+}
+
+// validateArtifactoryConfig validates the the configuration is not missing any required values
+func validateArtifactoryConfig(cfg artifactoryUploaderConfig) error {
+	requiredConfigs := map[string]string{
+		"ArtifactoryURL":  cfg.ArtifactoryURL,
+		"ArtifactoryRepo": cfg.ArtifactoryRepo,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// ObjectExists checks whether the object has already been uploaded to the Artifactory repo
+func (aru *artifactoryUploader) ObjectExists(targetObj string) (bool, error) {
+	params := services.NewSearchParams()
+	params.Pattern = fmt.Sprintf("%s/%s", aru.repo, targetObj)
+
+	resp, err := aru.artifactoryManager.SearchFiles(params)
+	if err != nil {
+		return false, err
+	}
+
+	return len(resp) > 0, nil
+}
+
+// PutObject uploads the file at sourcePath to the object specified in targetObj
+func (aru *artifactoryUploader) PutObject(sourcePath string, targetObj string) error {
+	params := services.NewUploadParams()
+	params.Pattern = sourcePath
+	params.Target = fmt.Sprintf("%s/%s", aru.repo, targetObj)
+
+	_, _, totalFailed, err := aru.artifactoryManager.UploadFiles(params)
+	if err != nil || totalFailed > 0 {
+		return fmt.Errorf("failed to upload file %q, %v", sourcePath, err)
+	}
+
+	return nil
+}
+
+// ListObjects lists the files in the Artifactory repo, relative to the repo
+func (aru *artifactoryUploader) ListObjects() ([]string, error) {
+	var objects []string
+
+	params := services.NewSearchParams()
+	params.Pattern = fmt.Sprintf("%s/*", aru.repo)
+	params.Recursive = true
+
+	resp, err := aru.artifactoryManager.SearchFiles(params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range resp {
+		objects = append(objects, path.Join(item.Path, item.Name))
+	}
+
+	return objects, nil
+}
+
+// DeleteObject deletes the object specified in targetObj from the Artifactory repo
+func (aru *artifactoryUploader) DeleteObject(targetObj string) error {
+	params := services.NewDeleteParams()
+	params.Pattern = fmt.Sprintf("%s/%s", aru.repo, targetObj)
+
+	items, err := aru.artifactoryManager.GetPathsToDelete(params)
+	if err != nil {
+		return err
+	}
+
+	_, err = aru.artifactoryManager.DeleteFiles(items)
+	return err
+}
@@ -0,0 +1,32 @@
+package uploader
+
+import (
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifactoryNew(t *testing.T) {
+	ul, err := New(config.UploaderConfig{
+		Type: "artifactory",
+		Config: map[interface{}]interface{}{
+			"artifactory_url":      "http://foo.bar",
+			"artifactory_username": "testing",
+			"artifactory_key":      "123",
+			"artifactory_repo":     "test",
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, ul)
+}
+
+func TestArtifactoryMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.UploaderConfig{
+		Type: "artifactory",
+		Config: map[interface{}]interface{}{
+			"artifactory_username": "testing",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: ArtifactoryRepo, ArtifactoryURL")
+}
@@ -0,0 +1,289 @@
+package uploader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// filesystemStateDir is the directory beneath the root holding the checksums and partially written files,
+// it is never listed as an object and objects cannot be written beneath it
+const filesystemStateDir = ".looking-glass"
+
+type filesystemUploaderConfig struct {
+	FilesystemRoot string `mapstructure:"filesystem_root"`
+}
+
+type filesystemUploader struct {
+	root string
+}
+
+// newFilesystem returns an initialized filesystemUploader struct
+func newFilesystem(config config.UploaderConfig) (Uploader, error) {
+	var cfg filesystemUploaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateFilesystemConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := &filesystemUploader{
+		root: filepath.Clean(cfg.FilesystemRoot),
+	}
+
+	return uploader, nil
+}
+
+// validateFilesystemConfig validates the the configuration is not missing any required values
+func validateFilesystemConfig(cfg filesystemUploaderConfig) error {
+	requiredConfigs := map[string]string{
+		"FilesystemRoot": cfg.FilesystemRoot,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// objectPath returns the path of the object beneath the root, object paths can never escape the root
+func (fsu *filesystemUploader) objectPath(targetObj string) string {
+	return filepath.Join(fsu.root, filepath.FromSlash(path.Clean("/"+targetObj)))
+}
+
+// checkObjectPath returns an error if the object would be beneath the state directory
+func checkObjectPath(targetObj string) error {
+	cleanPath := path.Clean("/" + targetObj)
+	if cleanPath == "/"+filesystemStateDir || strings.HasPrefix(cleanPath, "/"+filesystemStateDir+"/") {
+		return fmt.Errorf("object '%s' is beneath the reserved %s directory", targetObj, filesystemStateDir)
+	}
+
+	return nil
+}
+
+// checksumPath returns the path of the file holding the sha256 checksum of the object
+func (fsu *filesystemUploader) checksumPath(targetObj string) string {
+	return filepath.Join(fsu.root, filesystemStateDir, "checksums", filepath.FromSlash(path.Clean("/"+targetObj))+".sha256")
+}
+
+// statPath returns the path of the file holding the size and modification time the object had when its
+// checksum was last verified
+func (fsu *filesystemUploader) statPath(targetObj string) string {
+	return filepath.Join(fsu.root, filesystemStateDir, "checksums", filepath.FromSlash(path.Clean("/"+targetObj))+".stat")
+}
+
+// fileStat formats the size and modification time of the file for its stat file
+func fileStat(info os.FileInfo) string {
+	return fmt.Sprintf("%d %d\n", info.Size(), info.ModTime().UnixNano())
+}
+
+// writeFile writes the contents of r to a temporary file beneath the root and renames it to the
+// targetPath once complete, so a partially written file is never left at the targetPath
+func (fsu *filesystemUploader) writeFile(r io.Reader, targetPath string) error {
+	tmpDir := filepath.Join(fsu.root, filesystemStateDir, "tmp")
+	err := os.MkdirAll(tmpDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(tmpDir, filepath.Base(targetPath)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	_, err = io.Copy(tmpFile, r)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if err == nil {
+		err = tmpFile.Chmod(0644)
+	}
+	if err == nil {
+		err = tmpFile.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(targetPath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), targetPath)
+}
+
+// fileChecksum returns the hex encoded sha256 checksum of the file
+func fileChecksum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ObjectExists checks whether the object has already been written beneath the root and still matches
+// the checksum recorded when it was written, the object is only hashed again when its size or
+// modification time has changed since it was last verified
+func (fsu *filesystemUploader) ObjectExists(targetObj string) (bool, error) {
+	err := checkObjectPath(targetObj)
+	if err != nil {
+		return false, err
+	}
+
+	content, err := ioutil.ReadFile(fsu.checksumPath(targetObj))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	objectPath := fsu.objectPath(targetObj)
+	info, err := os.Stat(objectPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	stat, err := ioutil.ReadFile(fsu.statPath(targetObj))
+	if err == nil && string(stat) == fileStat(info) {
+		return true, nil
+	}
+
+	checksum, err := fileChecksum(objectPath)
+	if err != nil {
+		return false, err
+	}
+	if checksum != fields[0] {
+		return false, nil
+	}
+
+	// The file still matches, so record its new stat to avoid hashing it again on the next check
+	return true, fsu.writeFile(strings.NewReader(fileStat(info)), fsu.statPath(targetObj))
+}
+
+// PutObject copies the file at sourcePath to the object specified in targetObj beneath the root,
+// recording its checksum
+func (fsu *filesystemUploader) PutObject(sourcePath string, targetObj string) error {
+	err := checkObjectPath(targetObj)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Remove the old checksum first, so the object is never considered up to date if we fail part way
+	checksumPath := fsu.checksumPath(targetObj)
+	for _, statePath := range []string{checksumPath, fsu.statPath(targetObj)} {
+		err = os.Remove(statePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	objectPath := fsu.objectPath(targetObj)
+	h := sha256.New()
+	err = fsu.writeFile(io.TeeReader(f, h), objectPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(objectPath)
+	if err != nil {
+		return err
+	}
+	err = fsu.writeFile(strings.NewReader(fileStat(info)), fsu.statPath(targetObj))
+	if err != nil {
+		return err
+	}
+
+	// The checksum is stored in the sha256sum format so the export can also be verified by hand
+	checksum := fmt.Sprintf("%s  %s\n", hex.EncodeToString(h.Sum(nil)), path.Base(targetObj))
+	return fsu.writeFile(strings.NewReader(checksum), checksumPath)
+}
+
+// ListObjects lists the objects beneath the root, relative to the root
+func (fsu *filesystemUploader) ListObjects() ([]string, error) {
+	var objects []string
+
+	err := filepath.Walk(fsu.root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && filePath == filepath.Join(fsu.root, filesystemStateDir) {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(fsu.root, filePath)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, filepath.ToSlash(relPath))
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// DeleteObject deletes the object specified in targetObj and its checksum from beneath the root
+func (fsu *filesystemUploader) DeleteObject(targetObj string) error {
+	err := checkObjectPath(targetObj)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(fsu.objectPath(targetObj))
+	if err != nil {
+		return err
+	}
+
+	for _, statePath := range []string{fsu.checksumPath(targetObj), fsu.statPath(targetObj)} {
+		err = os.Remove(statePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
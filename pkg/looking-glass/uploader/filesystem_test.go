@@ -0,0 +1,173 @@
+package uploader
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesystemPutExistsAndList(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "filesystem-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "filesystem-dst")
+	defer os.RemoveAll(dstDir)
+
+	source := path.Join(srcDir, "tool.tar.gz")
+	ioutil.WriteFile(source, []byte("tarball"), 0644)
+
+	ul, err := New(config.UploaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": dstDir,
+		},
+	})
+	assert.NoError(t, err)
+
+	exists, err := ul.ObjectExists("vendor/v1.0/tool.tar.gz")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, ul.PutObject(source, "vendor/v1.0/tool.tar.gz"))
+
+	content, err := ioutil.ReadFile(path.Join(dstDir, "vendor/v1.0/tool.tar.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tarball", string(content))
+
+	exists, err = ul.ObjectExists("vendor/v1.0/tool.tar.gz")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	list, err := ul.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor/v1.0/tool.tar.gz"}, list)
+
+	// A file that no longer matches its checksum has to be mirrored again
+	ioutil.WriteFile(path.Join(dstDir, "vendor/v1.0/tool.tar.gz"), []byte("corrupt"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path.Join(dstDir, "vendor/v1.0/tool.tar.gz"), later, later)
+	exists, err = ul.ObjectExists("vendor/v1.0/tool.tar.gz")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, ul.DeleteObject("vendor/v1.0/tool.tar.gz"))
+	list, err = ul.ListObjects()
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestFilesystemObjectsStayBeneathRoot(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "filesystem-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "filesystem-dst")
+	defer os.RemoveAll(dstDir)
+
+	source := path.Join(srcDir, "file.txt")
+	ioutil.WriteFile(source, []byte("content"), 0644)
+
+	ul, err := New(config.UploaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": path.Join(dstDir, "root"),
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, ul.PutObject(source, "../escape.txt"))
+	_, err = os.Stat(path.Join(dstDir, "escape.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	list, err := ul.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"escape.txt"}, list)
+}
+
+func TestFilesystemExistsOnlyHashesChangedFiles(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "filesystem-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "filesystem-dst")
+	defer os.RemoveAll(dstDir)
+
+	source := path.Join(srcDir, "tool.tar.gz")
+	ioutil.WriteFile(source, []byte("tarball"), 0644)
+
+	ul, err := New(config.UploaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": dstDir,
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, ul.PutObject(source, "tool.tar.gz"))
+
+	// Content changed behind our back with the same size and modification time is not hashed again
+	target := path.Join(dstDir, "tool.tar.gz")
+	info, _ := os.Stat(target)
+	ioutil.WriteFile(target, []byte("corrupt"), 0644)
+	os.Chtimes(target, info.ModTime(), info.ModTime())
+
+	exists, err := ul.ObjectExists("tool.tar.gz")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Once the modification time changes the file is hashed and found not to match
+	later := info.ModTime().Add(time.Minute)
+	os.Chtimes(target, later, later)
+
+	exists, err = ul.ObjectExists("tool.tar.gz")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// A touched file that still matches is kept, and its new stat is recorded
+	ioutil.WriteFile(target, []byte("tarball"), 0644)
+	os.Chtimes(target, later, later)
+
+	exists, err = ul.ObjectExists("tool.tar.gz")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	stat, err := ioutil.ReadFile(path.Join(dstDir, ".looking-glass/checksums/tool.tar.gz.stat"))
+	assert.NoError(t, err)
+	info, _ = os.Stat(target)
+	assert.Equal(t, fileStat(info), string(stat))
+}
+
+func TestFilesystemRejectsStateDirObjects(t *testing.T) {
+	srcDir, _ := ioutil.TempDir("", "filesystem-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "filesystem-dst")
+	defer os.RemoveAll(dstDir)
+
+	source := path.Join(srcDir, "file.txt")
+	ioutil.WriteFile(source, []byte("content"), 0644)
+
+	ul, err := New(config.UploaderConfig{
+		Type: "filesystem",
+		Config: map[interface{}]interface{}{
+			"filesystem_root": dstDir,
+		},
+	})
+	assert.NoError(t, err)
+
+	err = ul.PutObject(source, ".looking-glass/checksums/file.txt.sha256")
+	assert.EqualError(t, err, "object '.looking-glass/checksums/file.txt.sha256' is beneath the reserved .looking-glass directory")
+
+	_, err = ul.ObjectExists("vendor/../.looking-glass/tmp")
+	assert.Error(t, err)
+	assert.Error(t, ul.DeleteObject(".looking-glass"))
+
+	// Objects merely starting with the same name are allowed
+	assert.NoError(t, ul.PutObject(source, ".looking-glass-backup/file.txt"))
+}
+
+func TestFilesystemMissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.UploaderConfig{
+		Type:   "filesystem",
+		Config: map[interface{}]interface{}{},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: FilesystemRoot")
+}
@@ -0,0 +1,227 @@
+package uploader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+type nexusUploaderConfig struct {
+	NexusURL      string `mapstructure:"nexus_url"`
+	NexusRepo     string `mapstructure:"nexus_repo"`
+	NexusUsername string `mapstructure:"nexus_username"`
+	NexusPassword string `mapstructure:"nexus_password"`
+}
+
+// nexusAsset is an asset returned by the assets and search APIs
+type nexusAsset struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// nexusAssetPage is a page of assets, the continuation token is empty on the last page
+type nexusAssetPage struct {
+	Items             []nexusAsset `json:"items"`
+	ContinuationToken string       `json:"continuationToken"`
+}
+
+type nexusUploader struct {
+	client   *http.Client
+	baseURL  string
+	repo     string
+	username string
+	password string
+}
+
+// newNexus returns an initialized nexusUploader struct
+func newNexus(config config.UploaderConfig) (Uploader, error) {
+	var cfg nexusUploaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateNexusConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := &nexusUploader{
+		client:   http.DefaultClient,
+		baseURL:  strings.TrimSuffix(cfg.NexusURL, "/"),
+		repo:     cfg.NexusRepo,
+		username: cfg.NexusUsername,
+		password: cfg.NexusPassword,
+	}
+
+	return uploader, nil
+}
+
+// validateNexusConfig validates the the configuration is not missing any required values
+func validateNexusConfig(cfg nexusUploaderConfig) error {
+	requiredConfigs := map[string]string{
+		"NexusURL":  cfg.NexusURL,
+		"NexusRepo": cfg.NexusRepo,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// do sends a request to the REST API path and decodes any JSON response into v
+func (nu *nexusUploader) do(method string, apiPath string, query url.Values, contentType string, body io.Reader, v interface{}) error {
+	reqURL := nu.baseURL + "/service/rest/v1/" + apiPath
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, reqURL, body)
+	if err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if nu.username != "" {
+		req.SetBasicAuth(nu.username, nu.password)
+	}
+
+	resp, err := nu.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s returned %s", method, req.URL, resp.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// listAssets lists the assets of the search or assets API, following the continuation tokens
+func (nu *nexusUploader) listAssets(apiPath string, query url.Values) ([]nexusAsset, error) {
+	var assets []nexusAsset
+
+	query.Set("repository", nu.repo)
+	for {
+		var page nexusAssetPage
+		err := nu.do(http.MethodGet, apiPath, query, "", nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, page.Items...)
+
+		if page.ContinuationToken == "" {
+			return assets, nil
+		}
+		query.Set("continuationToken", page.ContinuationToken)
+	}
+}
+
+// findAsset searches the repo for the asset at the object path, returning nil if there is none
+func (nu *nexusUploader) findAsset(targetObj string) (*nexusAsset, error) {
+	assets, err := nu.listAssets("search/assets", url.Values{"name": {targetObj}})
+	if err != nil {
+		return nil, err
+	}
+
+	// Depending on the Nexus version raw asset paths may or may not start with a slash
+	for _, asset := range assets {
+		if strings.TrimPrefix(asset.Path, "/") == targetObj {
+			return &asset, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ObjectExists checks whether the object has already been uploaded to the Nexus repo
+func (nu *nexusUploader) ObjectExists(targetObj string) (bool, error) {
+	asset, err := nu.findAsset(targetObj)
+	if err != nil {
+		return false, err
+	}
+
+	return asset != nil, nil
+}
+
+// PutObject uploads the file at sourcePath to the object specified in targetObj as a raw component
+func (nu *nexusUploader) PutObject(sourcePath string, targetObj string) error {
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	directory := path.Dir(targetObj)
+	if directory == "." {
+		directory = "/"
+	}
+
+	// Stream the multipart form so large files are never held in memory
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := mw.WriteField("raw.directory", directory)
+		if err == nil {
+			err = mw.WriteField("raw.asset1.filename", path.Base(targetObj))
+		}
+		if err == nil {
+			var part io.Writer
+			part, err = mw.CreateFormFile("raw.asset1", path.Base(targetObj))
+			if err == nil {
+				_, err = io.Copy(part, f)
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = nu.do(http.MethodPost, "components", url.Values{"repository": {nu.repo}}, mw.FormDataContentType(), pr, nil)
+	pr.Close()
+	return err
+}
+
+// ListObjects lists the assets in the Nexus repo
+func (nu *nexusUploader) ListObjects() ([]string, error) {
+	var objects []string
+
+	assets, err := nu.listAssets("assets", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, asset := range assets {
+		objects = append(objects, strings.TrimPrefix(asset.Path, "/"))
+	}
+
+	return objects, nil
+}
+
+// DeleteObject deletes the asset at the object path from the Nexus repo
+func (nu *nexusUploader) DeleteObject(targetObj string) error {
+	asset, err := nu.findAsset(targetObj)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return fmt.Errorf("object '%s' not found", targetObj)
+	}
+
+	return nu.do(http.MethodDelete, "assets/"+url.PathEscape(asset.ID), nil, "", nil, nil)
+}
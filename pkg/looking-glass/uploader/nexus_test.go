@@ -0,0 +1,147 @@
+package uploader
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNexus serves a minimal Nexus REST API for a raw repo named test, storing assets in memory
+// and returning one asset per page
+func newTestNexus(t *testing.T, assets map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "admin", user)
+		assert.Equal(t, "secret", pass)
+		// Assets are deleted by their ID alone, every other request is scoped to the repository
+		if r.Method != http.MethodDelete {
+			assert.Equal(t, "test", r.URL.Query().Get("repository"))
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/service/rest/v1/components":
+			f, _, err := r.FormFile("raw.asset1")
+			assert.NoError(t, err)
+			content, _ := ioutil.ReadAll(f)
+			assetPath := path.Join(r.FormValue("raw.directory"), r.FormValue("raw.asset1.filename"))
+			assets[path.Join("/", assetPath)] = string(content)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && (r.URL.Path == "/service/rest/v1/assets" || r.URL.Path == "/service/rest/v1/search/assets"):
+			var items []nexusAsset
+			for assetPath := range assets {
+				name := r.URL.Query().Get("name")
+				if name == "" || "/"+name == assetPath {
+					items = append(items, nexusAsset{ID: "id" + assetPath, Path: assetPath})
+				}
+			}
+			sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+
+			page := nexusAssetPage{}
+			if r.URL.Query().Get("continuationToken") != "" {
+				items = items[1:]
+			}
+			if len(items) > 1 {
+				page.ContinuationToken = "next"
+			}
+			if len(items) > 0 {
+				page.Items = items[:1]
+			}
+			json.NewEncoder(w).Encode(page)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/service/rest/v1/assets/"):
+			assetPath := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/service/rest/v1/assets/"), "id")
+			if _, ok := assets[assetPath]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			delete(assets, assetPath)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestNexusPutAndExists(t *testing.T) {
+	assets := map[string]string{}
+	server := newTestNexus(t, assets)
+	defer server.Close()
+
+	ul, err := New(config.UploaderConfig{
+		Type: "nexus",
+		Config: map[interface{}]interface{}{
+			"nexus_url":      server.URL + "/",
+			"nexus_repo":     "test",
+			"nexus_username": "admin",
+			"nexus_password": "secret",
+		},
+	})
+	assert.NoError(t, err)
+
+	exists, err := ul.ObjectExists("dir/file.txt")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	tmpFile, _ := ioutil.TempFile("", "nexus-uploader")
+	defer os.Remove(tmpFile.Name())
+	tmpFile.WriteString("content")
+	tmpFile.Close()
+
+	assert.NoError(t, ul.PutObject(tmpFile.Name(), "dir/file.txt"))
+	assert.Equal(t, map[string]string{"/dir/file.txt": "content"}, assets)
+
+	exists, err = ul.ObjectExists("dir/file.txt")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestNexusListObjects(t *testing.T) {
+	server := newTestNexus(t, map[string]string{"/a.txt": "a", "/b/c.txt": "c"})
+	defer server.Close()
+
+	ul, err := New(config.UploaderConfig{
+		Type: "nexus",
+		Config: map[interface{}]interface{}{
+			"nexus_url":      server.URL,
+			"nexus_repo":     "test",
+			"nexus_username": "admin",
+			"nexus_password": "secret",
+		},
+	})
+	assert.NoError(t, err)
+
+	objects, err := ul.ListObjects()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.txt", "b/c.txt"}, objects)
+}
+
+func TestNexusDeleteObject(t *testing.T) {
+	assets := map[string]string{"/a.txt": "a", "/b/c.txt": "c"}
+	server := newTestNexus(t, assets)
+	defer server.Close()
+
+	ul, err := New(config.UploaderConfig{
+		Type: "nexus",
+		Config: map[interface{}]interface{}{
+			"nexus_url":      server.URL,
+			"nexus_repo":     "test",
+			"nexus_username": "admin",
+			"nexus_password": "secret",
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, ul.DeleteObject("b/c.txt"))
+	assert.Equal(t, map[string]string{"/a.txt": "a"}, assets)
+
+	err = ul.DeleteObject("b/c.txt")
+	assert.EqualError(t, err, "object 'b/c.txt' not found")
+}
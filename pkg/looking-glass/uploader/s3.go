@@ -0,0 +1,145 @@
+package uploader
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	sss "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/mitchellh/mapstructure"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/awsutil"
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+type s3UploaderConfig struct {
+	AwsBucket         string `mapstructure:"aws_bucket"`
+	AwsPrefix         string `mapstructure:"aws_prefix"`
+	AwsKey            string `mapstructure:"aws_key"`
+	AwsSecret         string `mapstructure:"aws_secret"`
+	AwsRegion         string `mapstructure:"aws_region"`
+	AwsEndpoint       string `mapstructure:"aws_endpoint"`
+	AwsForcePathStyle bool   `mapstructure:"aws_force_path_style"`
+}
+
+type s3Uploader struct {
+	awsSession *session.Session
+	awsBucket  string
+	awsPrefix  string
+}
+
+// newS3 returns an initialized s3Uploader struct
+func newS3(config config.UploaderConfig) (Uploader, error) {
+	var cfg s3UploaderConfig
+	err := mapstructure.Decode(config.Config, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateS3Config(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	awsSess, err := awsutil.NewSession(cfg.AwsKey, cfg.AwsSecret, cfg.AwsRegion, cfg.AwsEndpoint, cfg.AwsForcePathStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := &s3Uploader{
+		awsSession: awsSess,
+		awsBucket:  cfg.AwsBucket,
+		awsPrefix:  strings.Trim(cfg.AwsPrefix, "/"),
+	}
+
+	return uploader, nil
+}
+
+// validateS3Config validates the the configuration is not missing any required values
+func validateS3Config(cfg s3UploaderConfig) error {
+	requiredConfigs := map[string]string{
+		"AwsBucket": cfg.AwsBucket,
+		"AwsRegion": cfg.AwsRegion,
+	}
+
+	return config.CheckRequired(requiredConfigs)
+}
+
+// objectKey returns the key of the object in the bucket, beneath the prefix
+func (su *s3Uploader) objectKey(targetObj string) string {
+	if su.awsPrefix == "" {
+		return targetObj
+	}
+	return su.awsPrefix + "/" + targetObj
+}
+
+// ObjectExists checks whether the object has already been uploaded to the S3 bucket
+func (su *s3Uploader) ObjectExists(targetObj string) (bool, error) {
+	_, err := sss.New(su.awsSession).HeadObject(&sss.HeadObjectInput{
+		Bucket: aws.String(su.awsBucket),
+		Key:    aws.String(su.objectKey(targetObj)),
+	})
+	if err != nil {
+		// HEAD responses have no body, so a missing object only shows up as a NotFound code
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// PutObject uploads the file at sourcePath to the object specified in targetObj
+func (su *s3Uploader) PutObject(sourcePath string, targetObj string) error {
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = s3manager.NewUploader(su.awsSession).Upload(&s3manager.UploadInput{
+		Bucket: aws.String(su.awsBucket),
+		Key:    aws.String(su.objectKey(targetObj)),
+		Body:   f,
+	})
+
+	return err
+}
+
+// ListObjects lists the objects beneath the prefix in the S3 bucket, relative to the prefix
+func (su *s3Uploader) ListObjects() ([]string, error) {
+	var objects []string
+
+	input := &sss.ListObjectsV2Input{
+		Bucket: aws.String(su.awsBucket),
+	}
+	if su.awsPrefix != "" {
+		input.Prefix = aws.String(su.awsPrefix + "/")
+	}
+
+	err := sss.New(su.awsSession).
+		ListObjectsV2Pages(input, func(page *sss.ListObjectsV2Output, lastPage bool) bool {
+			for _, obj := range page.Contents {
+				objects = append(objects, strings.TrimPrefix(*obj.Key, aws.StringValue(input.Prefix)))
+			}
+			return !lastPage
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// DeleteObject deletes the object specified in targetObj from the S3 bucket
+func (su *s3Uploader) DeleteObject(targetObj string) error {
+	_, err := sss.New(su.awsSession).DeleteObject(&sss.DeleteObjectInput{
+		Bucket: aws.String(su.awsBucket),
+		Key:    aws.String(su.objectKey(targetObj)),
+	})
+
+	return err
+}
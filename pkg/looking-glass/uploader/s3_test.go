@@ -0,0 +1,104 @@
+package uploader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestS3 serves a minimal path style S3 API for a bucket named test, storing objects in memory
+func newTestS3(objects map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/test" || r.URL.Path == "/test/" {
+			var keys []string
+			for key := range objects {
+				if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			fmt.Fprint(w, `<ListBucketResult><Name>test</Name><IsTruncated>false</IsTruncated>`)
+			for _, key := range keys {
+				fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", key)
+			}
+			fmt.Fprint(w, `</ListBucketResult>`)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/test/")
+		switch r.Method {
+		case http.MethodHead:
+			if _, ok := objects[key]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			content, _ := ioutil.ReadAll(r.Body)
+			objects[key] = string(content)
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestS3PutExistsAndList(t *testing.T) {
+	objects := map[string]string{"other/file.txt": "other"}
+	server := newTestS3(objects)
+	defer server.Close()
+
+	ul, err := New(config.UploaderConfig{
+		Type: "s3",
+		Config: map[interface{}]interface{}{
+			"aws_bucket":           "test",
+			"aws_prefix":           "/mirror/",
+			"aws_key":              "key",
+			"aws_secret":           "secret",
+			"aws_region":           "us-east-1",
+			"aws_endpoint":         server.URL,
+			"aws_force_path_style": true,
+		},
+	})
+	assert.NoError(t, err)
+
+	exists, err := ul.ObjectExists("dir/file.txt")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	tmpFile, _ := ioutil.TempFile("", "s3-uploader")
+	defer os.Remove(tmpFile.Name())
+	tmpFile.WriteString("content")
+	tmpFile.Close()
+
+	assert.NoError(t, ul.PutObject(tmpFile.Name(), "dir/file.txt"))
+	assert.Equal(t, "content", objects["mirror/dir/file.txt"])
+
+	exists, err = ul.ObjectExists("dir/file.txt")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	list, err := ul.ListObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dir/file.txt"}, list)
+
+	assert.NoError(t, ul.DeleteObject("dir/file.txt"))
+	assert.Equal(t, map[string]string{"other/file.txt": "other"}, objects)
+}
+
+func TestS3MissingRequiredConfigs(t *testing.T) {
+	_, err := New(config.UploaderConfig{
+		Type: "s3",
+		Config: map[interface{}]interface{}{
+			"aws_endpoint": "http://localhost:9000",
+		},
+	})
+	assert.EqualError(t, err, "configuration values cannot be empty: AwsBucket, AwsRegion")
+}
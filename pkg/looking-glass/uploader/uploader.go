@@ -0,0 +1,31 @@
+package uploader
+
+import (
+	"fmt"
+
+	"github.com/simplifi/looking-glass/pkg/looking-glass/config"
+)
+
+// Uploader uploads objects to various destinations
+type Uploader interface {
+	ObjectExists(string) (bool, error)
+	PutObject(string, string) error
+	ListObjects() ([]string, error)
+	DeleteObject(string) error
+}
+
+// New Uploader, pass in the UploaderConfig
+func New(config config.UploaderConfig) (Uploader, error) {
+	switch config.Type {
+	case "artifactory":
+		return newArtifactory(config)
+	case "nexus":
+		return newNexus(config)
+	case "s3":
+		return newS3(config)
+	case "filesystem":
+		return newFilesystem(config)
+	default:
+		return nil, fmt.Errorf("unknown type %s", config.Type)
+	}
+}